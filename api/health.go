@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+const (
+	healthStatusOK          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+// HealthChecker is a component reporting its health
+type HealthChecker interface {
+	// LivenessChecks returns the checks failing if the component is wedged and should be restarted, e.g. the
+	// heartbeats of its routines
+	LivenessChecks() map[string]util.HealthCheck
+	// ReadinessChecks returns the checks failing if the component can not do its work for now, e.g. the rpc
+	// endpoints are unreachable
+	ReadinessChecks() map[string]util.HealthCheck
+}
+
+// HealthStatus is the result of the health checks, the checks failed are given their errors
+type HealthStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// AddHealthChecker adds the checks of the component to /healthz and /readyz
+func (s *Server) AddHealthChecker(checker HealthChecker) {
+	for name, check := range checker.LivenessChecks() {
+		s.liveness[name] = check
+	}
+	for name, check := range checker.ReadinessChecks() {
+		s.readiness[name] = check
+	}
+}
+
+// healthz serves /healthz, it fails if any liveness check fails
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, s.liveness)
+}
+
+// readyz serves /readyz, it fails if any liveness or readiness check fails
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	checks := make(map[string]util.HealthCheck, len(s.liveness)+len(s.readiness))
+	for name, check := range s.liveness {
+		checks[name] = check
+	}
+	for name, check := range s.readiness {
+		checks[name] = check
+	}
+	writeHealth(w, checks)
+}
+
+// writeHealth runs the checks and returns 200 if all of them pass, 503 otherwise
+func writeHealth(w http.ResponseWriter, checks map[string]util.HealthCheck) {
+	status := HealthStatus{Status: healthStatusOK, Checks: make(map[string]string, len(checks))}
+	for name, check := range checks {
+		if err := check(); err != nil {
+			util.Logger.Errorf("health check failed, check=%s, err=%s", name, err.Error())
+			status.Status = healthStatusUnavailable
+			status.Checks[name] = err.Error()
+			continue
+		}
+		status.Checks[name] = healthStatusOK
+	}
+
+	code := http.StatusOK
+	if status.Status != healthStatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, status)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// Server is the http server of a component, it serves the tasks shared by the components, the prometheus metrics,
+// the health checks, and the status of the component registered by it
+type Server struct {
+	Store store.Store
+
+	addr      string
+	mux       *http.ServeMux
+	liveness  map[string]util.HealthCheck
+	readiness map[string]util.HealthCheck
+}
+
+// NewServer returns the server listening on the address of the config
+func NewServer(cfg *util.HTTPConfig, st store.Store) *Server {
+	s := &Server{
+		Store:    st,
+		addr:     cfg.ListenAddr,
+		mux:      http.NewServeMux(),
+		liveness: make(map[string]util.HealthCheck),
+		readiness: map[string]util.HealthCheck{
+			"db": st.Ping,
+		},
+	}
+	s.HandleJSON("/tasks", s.listTasks)
+	s.HandleJSON("/tasks/", s.getTask)
+	s.Handle("/metrics", promhttp.Handler())
+	s.mux.HandleFunc("/healthz", s.healthz)
+	s.mux.HandleFunc("/readyz", s.readyz)
+	return s
+}
+
+// Handle registers the handler of the path pattern
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// HandleJSON registers the function serving the get requests of the path pattern, its result is returned in json
+func (s *Server) HandleJSON(pattern string, fn func(r *http.Request) (interface{}, error)) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "only GET is supported"})
+			return
+		}
+
+		result, err := fn(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+// Start serves the requests in the background, the component keeps running if the server fails
+func (s *Server) Start() {
+	server := &http.Server{
+		Addr:         s.addr,
+		Handler:      s.mux,
+		ReadTimeout:  common.APIReadTimeout,
+		WriteTimeout: common.APIWriteTimeout,
+	}
+	go func() {
+		util.Logger.Infof("http server started, addr=%s", s.addr)
+		if err := server.ListenAndServe(); err != nil {
+			util.Logger.Errorf("http server stopped, addr=%s, err=%s", s.addr, err.Error())
+		}
+	}()
+}
+
+// RequestError is the error of an invalid request
+type RequestError struct {
+	msg string
+}
+
+func (e *RequestError) Error() string {
+	return e.msg
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &RequestError{msg: fmt.Sprintf(format, args...)}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError returns the error with the status code of its kind
+func writeError(w http.ResponseWriter, err error) {
+	var requestErr *RequestError
+	switch {
+	case errors.As(err, &requestErr):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, store.ErrNotFound):
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+	default:
+		util.Logger.Errorf("serve http request error, err=%s", err.Error())
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		util.Logger.Errorf("write http response error, err=%s", err.Error())
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	s := NewServer(&util.HTTPConfig{ListenAddr: ":0"}, store.NewMemoryStore())
+	ts := httptest.NewServer(s.mux)
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// get requests the path and decodes the json response, it returns the status code
+func get(t *testing.T, ts *httptest.Server, path string, value interface{}) int {
+	res, err := http.Get(ts.URL + path)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(res.Body).Decode(value))
+	return res.StatusCode
+}
+
+func TestListTasks(t *testing.T) {
+	s, ts := newTestServer(t)
+	for taskId := int64(1); taskId <= 3; taskId++ {
+		require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{TaskId: taskId, Operator: "a", Status: model.ExecutionTaskStatusStatusInit}))
+	}
+	require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{TaskId: 4, Operator: "b", Status: model.ExecutionTaskStatusStatusInit}))
+	require.NoError(t, s.Store.MarkExecuted(2, store.ExecutionReceipt{GasUsed: 10, ResultStatus: model.ExecutionResultStatusOutOfGas}))
+
+	var list TaskList
+	require.Equal(t, http.StatusOK, get(t, ts, "/tasks?operator=a&limit=2", &list))
+	require.Len(t, list.Tasks, 2)
+	require.Equal(t, int64(3), list.Tasks[0].TaskId)
+	require.Equal(t, "init", list.Tasks[0].Status)
+	require.Nil(t, list.Tasks[0].Receipt)
+	require.Equal(t, int64(2), list.Tasks[1].TaskId)
+	require.Equal(t, &Receipt{GasUsed: 10, ResultStatus: "out_of_gas"}, list.Tasks[1].Receipt)
+	require.Equal(t, int64(2), list.NextBefore)
+
+	list = TaskList{}
+	require.Equal(t, http.StatusOK, get(t, ts, "/tasks?operator=a&limit=2&before=2", &list))
+	require.Len(t, list.Tasks, 1)
+	require.Equal(t, int64(1), list.Tasks[0].TaskId)
+	require.Equal(t, int64(0), list.NextBefore)
+
+	list = TaskList{}
+	require.Equal(t, http.StatusOK, get(t, ts, "/tasks?status=executed", &list))
+	require.Len(t, list.Tasks, 1)
+	require.Equal(t, int64(2), list.Tasks[0].TaskId)
+
+	var errRes errorResponse
+	require.Equal(t, http.StatusBadRequest, get(t, ts, "/tasks?status=done", &errRes))
+	require.Equal(t, "unknown execution task status done", errRes.Error)
+	require.Equal(t, http.StatusBadRequest, get(t, ts, "/tasks?limit=0", &errRes))
+}
+
+func TestGetTask(t *testing.T) {
+	s, ts := newTestServer(t)
+	require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{TaskId: 1, Status: model.ExecutionTaskStatusStatusInit}))
+	require.NoError(t, s.Store.AddTaskEvent(&model.TaskEvent{TaskId: 1, Event: model.TaskEventFailed, Worker: "executor", Error: "download failed"}))
+
+	var detail TaskDetail
+	require.Equal(t, http.StatusOK, get(t, ts, "/tasks/1", &detail))
+	require.Equal(t, int64(1), detail.Task.TaskId)
+	require.Len(t, detail.Events, 2)
+	require.Equal(t, model.TaskEventCreated, detail.Events[0].Event)
+	require.Equal(t, "init", detail.Events[0].Status)
+	require.Equal(t, "download failed", detail.Events[1].Error)
+	require.Equal(t, TaskPhases{}, detail.Phases)
+
+	var errRes errorResponse
+	require.Equal(t, http.StatusNotFound, get(t, ts, "/tasks/2", &errRes))
+	require.Equal(t, http.StatusBadRequest, get(t, ts, "/tasks/abc", &errRes))
+
+	res, err := http.Post(ts.URL+"/tasks/1", "application/json", nil)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+}
+
+func TestHandleJSON(t *testing.T) {
+	s, ts := newTestServer(t)
+	s.HandleJSON("/status", func(r *http.Request) (interface{}, error) {
+		return map[string]int64{"height": 10}, nil
+	})
+	s.HandleJSON("/broken", func(r *http.Request) (interface{}, error) {
+		return nil, errors.New("db closed")
+	})
+
+	var status map[string]int64
+	require.Equal(t, http.StatusOK, get(t, ts, "/status", &status))
+	require.Equal(t, int64(10), status["height"])
+
+	var errRes errorResponse
+	require.Equal(t, http.StatusInternalServerError, get(t, ts, "/broken", &errRes))
+	require.Equal(t, "db closed", errRes.Error)
+}
+
+func TestMetrics(t *testing.T) {
+	_, ts := newTestServer(t)
+	res, err := http.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "go_goroutines")
+}
+
+type fakeHealthChecker struct {
+	loopErr error
+	rpcErr  error
+}
+
+func (c *fakeHealthChecker) LivenessChecks() map[string]util.HealthCheck {
+	return map[string]util.HealthCheck{"loop": func() error { return c.loopErr }}
+}
+
+func (c *fakeHealthChecker) ReadinessChecks() map[string]util.HealthCheck {
+	return map[string]util.HealthCheck{"rpc": func() error { return c.rpcErr }}
+}
+
+func TestHealth(t *testing.T) {
+	s, ts := newTestServer(t)
+	checker := &fakeHealthChecker{}
+	s.AddHealthChecker(checker)
+
+	var status HealthStatus
+	require.Equal(t, http.StatusOK, get(t, ts, "/healthz", &status))
+	require.Equal(t, HealthStatus{Status: "ok", Checks: map[string]string{"loop": "ok"}}, status)
+	status = HealthStatus{}
+	require.Equal(t, http.StatusOK, get(t, ts, "/readyz", &status))
+	require.Equal(t, HealthStatus{Status: "ok", Checks: map[string]string{"db": "ok", "loop": "ok", "rpc": "ok"}}, status)
+
+	// not ready, but alive
+	checker.rpcErr = errors.New("no reachable rpc endpoint of 1")
+	status = HealthStatus{}
+	require.Equal(t, http.StatusOK, get(t, ts, "/healthz", &status))
+	require.Equal(t, http.StatusServiceUnavailable, get(t, ts, "/readyz", &status))
+	require.Equal(t, "unavailable", status.Status)
+	require.Equal(t, "no reachable rpc endpoint of 1", status.Checks["rpc"])
+
+	// a wedged component is not ready either
+	checker.rpcErr = nil
+	checker.loopErr = errors.New("no heartbeat for 3m0s, timeout=2m0s")
+	status = HealthStatus{}
+	require.Equal(t, http.StatusServiceUnavailable, get(t, ts, "/healthz", &status))
+	require.Equal(t, http.StatusServiceUnavailable, get(t, ts, "/readyz", &status))
+	require.Equal(t, "ok", status.Checks["rpc"])
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
+)
+
+// Task is an execution task in the responses
+type Task struct {
+	TaskId            int64  `json:"task_id"`
+	InvokeTxHash      string `json:"invoke_tx_hash"`
+	Operator          string `json:"operator"`
+	ExecutionObjectId string `json:"execution_object_id"`
+	InputFiles        string `json:"input_files"`
+	MaxGas            string `json:"max_gas"`
+	InvokeMethod      string `json:"invoke_method"`
+	Params            string `json:"params"`
+
+	Status          string      `json:"status"`
+	RejectReason    string      `json:"reject_reason,omitempty"`
+	LeaseOwner      string      `json:"lease_owner,omitempty"`
+	LeaseExpireTime int64       `json:"lease_expire_time,omitempty"`
+	Receipt         *Receipt    `json:"receipt,omitempty"`    // set once the task is executed
+	Submission      *Submission `json:"submission,omitempty"` // set once the result is submitted
+	CreateTime      int64       `json:"create_time"`
+	UpdateTime      int64       `json:"update_time"`
+}
+
+// Receipt is the result of an executed task
+type Receipt struct {
+	GasUsed         int64  `json:"gas_used"`
+	ResultStatus    string `json:"result_status"`
+	ExecutionStatus string `json:"execution_status"`
+	ResultDataUri   string `json:"result_data_uri"`
+	LogDataUri      string `json:"log_data_uri"`
+	AttestationUri  string `json:"attestation_uri"`
+}
+
+// Submission is the submission of the result of a task on chain
+type Submission struct {
+	Account         string `json:"account"`
+	TxHash          string `json:"tx_hash"`
+	TxHeight        int64  `json:"tx_height"`
+	TxCode          uint32 `json:"tx_code"`
+	Attempts        int    `json:"attempts"`
+	SubmitTime      int64  `json:"submit_time"`
+	NextSubmitTime  int64  `json:"next_submit_time"`
+	Error           string `json:"error,omitempty"`
+	ResultSubmitter string `json:"result_submitter,omitempty"`
+}
+
+// TaskEvent is a transition of a task in the responses
+type TaskEvent struct {
+	Event     model.TaskEventType `json:"event"`
+	Status    string              `json:"status"`
+	Worker    string              `json:"worker,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	EventTime int64               `json:"event_time"` // unix time in milliseconds
+}
+
+// TaskPhases is the time in milliseconds a task spent in each phase
+type TaskPhases struct {
+	Queue      int64 `json:"queue_ms"`
+	Download   int64 `json:"download_ms"`
+	Execution  int64 `json:"execution_ms"`
+	Upload     int64 `json:"upload_ms"`
+	Submission int64 `json:"submission_ms"`
+}
+
+type TaskList struct {
+	Tasks []Task `json:"tasks"`
+	// NextBefore is the "before" parameter listing the next page, 0 if there is no more
+	NextBefore int64 `json:"next_before,omitempty"`
+}
+
+type TaskDetail struct {
+	Task   Task        `json:"task"`
+	Events []TaskEvent `json:"events"`
+	Phases TaskPhases  `json:"phases"`
+}
+
+// NewTask returns the task in the responses
+func NewTask(task *model.ExecutionTask) Task {
+	t := Task{
+		TaskId:            task.TaskId,
+		InvokeTxHash:      task.InvokeTxHash,
+		Operator:          task.Operator,
+		ExecutionObjectId: task.ExecutionObjectId,
+		InputFiles:        task.InputFiles,
+		MaxGas:            task.MaxGas,
+		InvokeMethod:      task.InvokeMethod,
+		Params:            task.Params,
+		Status:            task.Status.String(),
+		RejectReason:      task.RejectReason,
+		LeaseOwner:        task.LeaseOwner,
+		LeaseExpireTime:   task.LeaseExpireTime,
+		CreateTime:        task.CreateTime,
+		UpdateTime:        task.UpdateTime,
+	}
+	if task.Status != model.ExecutionTaskStatusStatusInit && task.Status != model.ExecutionTaskStatusStatusRejected {
+		t.Receipt = &Receipt{
+			GasUsed:         task.GasUsed,
+			ResultStatus:    task.ResultStatus.String(),
+			ExecutionStatus: task.ExecutionStatus,
+			ResultDataUri:   task.ResultDataUri,
+			LogDataUri:      task.LogDataUri,
+			AttestationUri:  task.AttestationUri,
+		}
+	}
+	if task.SubmitAttempts > 0 || task.ResultSubmitter != "" {
+		t.Submission = &Submission{
+			Account:         task.SubmitAccount,
+			TxHash:          task.SubmitTxHash,
+			TxHeight:        task.SubmitTxHeight,
+			TxCode:          task.SubmitTxCode,
+			Attempts:        task.SubmitAttempts,
+			SubmitTime:      task.SubmitTime,
+			NextSubmitTime:  task.NextSubmitTime,
+			Error:           task.SubmitError,
+			ResultSubmitter: task.ResultSubmitter,
+		}
+	}
+	return t
+}
+
+// listTasks serves /tasks?status=&operator=&before=&limit=, the tasks are listed in descending order of task id
+func (s *Server) listTasks(r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	filter := store.TaskFilter{
+		Operator: query.Get("operator"),
+		Limit:    common.APIDefaultListLimit,
+	}
+	if status := query.Get("status"); status != "" {
+		taskStatus, err := model.ParseExecutionTaskStatus(status)
+		if err != nil {
+			return nil, badRequest("%s", err.Error())
+		}
+		filter.Status = &taskStatus
+	}
+	if before := query.Get("before"); before != "" {
+		taskId, err := strconv.ParseInt(before, 10, 64)
+		if err != nil || taskId <= 0 {
+			return nil, badRequest("invalid before %s", before)
+		}
+		filter.BeforeTaskId = taskId
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > common.APIMaxListLimit {
+			return nil, badRequest("limit should be between 1 and %d", common.APIMaxListLimit)
+		}
+		filter.Limit = n
+	}
+
+	tasks, err := s.Store.ListTasks(filter)
+	if err != nil {
+		return nil, err
+	}
+	list := TaskList{Tasks: make([]Task, 0, len(tasks))}
+	for idx := range tasks {
+		list.Tasks = append(list.Tasks, NewTask(&tasks[idx]))
+	}
+	if len(tasks) == filter.Limit {
+		list.NextBefore = tasks[len(tasks)-1].TaskId
+	}
+	return list, nil
+}
+
+// getTask serves /tasks/{task_id}, the task along with its events and phases
+func (s *Server) getTask(r *http.Request) (interface{}, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/tasks/")
+	taskId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, badRequest("invalid task id %s", id)
+	}
+
+	task, err := s.Store.GetTask(taskId)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.Store.TaskEvents(taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := TaskDetail{Task: NewTask(task), Events: make([]TaskEvent, 0, len(events))}
+	for _, event := range events {
+		detail.Events = append(detail.Events, TaskEvent{
+			Event:     event.Event,
+			Status:    event.Status.String(),
+			Worker:    event.Worker,
+			Error:     event.Error,
+			EventTime: event.EventTime,
+		})
+	}
+	phases := model.TaskPhasesOf(events)
+	detail.Phases = TaskPhases{
+		Queue:      phases.Queue.Milliseconds(),
+		Download:   phases.Download.Milliseconds(),
+		Execution:  phases.Execution.Milliseconds(),
+		Upload:     phases.Upload.Milliseconds(),
+		Submission: phases.Submission.Milliseconds(),
+	}
+	return detail, nil
+}
@@ -0,0 +1,111 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bnb-chain/greenfield-go-sdk/types"
+
+	"github.com/bnb-chain/greenfield-execution-provider/client"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// Archiver archives the pruned rows before they are deleted
+type Archiver interface {
+	Archive(name string, rows []interface{}) error
+}
+
+// FileArchiver writes the rows to gzip compressed jsonl files
+type FileArchiver struct {
+	Dir string
+}
+
+func NewFileArchiver(dir string) *FileArchiver {
+	return &FileArchiver{
+		Dir: dir,
+	}
+}
+
+// Archive writes the rows to <dir>/<name>.jsonl.gz, one row per line
+func (a *FileArchiver) Archive(name string, rows []interface{}) error {
+	_, err := a.archive(name, rows)
+	return err
+}
+
+func (a *FileArchiver) archive(name string, rows []interface{}) (string, error) {
+	if err := os.MkdirAll(a.Dir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(zw)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(a.Dir, name+".jsonl.gz")
+	if err := os.WriteFile(filePath, buf.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// GreenfieldArchiver writes the rows to local files like FileArchiver and uploads the files to a
+// greenfield bucket
+type GreenfieldArchiver struct {
+	fileArchiver *FileArchiver
+	clients      *client.SDKClients
+	bucketName   string
+}
+
+func NewGreenfieldArchiver(dir string, clients *client.SDKClients, bucketName string) *GreenfieldArchiver {
+	return &GreenfieldArchiver{
+		fileArchiver: NewFileArchiver(dir),
+		clients:      clients,
+		bucketName:   bucketName,
+	}
+}
+
+// Archive uploads the rows as object <name>.jsonl.gz
+func (a *GreenfieldArchiver) Archive(name string, rows []interface{}) error {
+	filePath, err := a.fileArchiver.archive(name, rows)
+	if err != nil {
+		return err
+	}
+
+	dataBuf, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	cli := a.clients.Client()
+	objectName := filepath.Base(filePath)
+	createTx, err := cli.CreateObject(context.Background(), a.bucketName, objectName, bytes.NewReader(dataBuf), types.CreateObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("create archive object error, object=%s, err=%s", objectName, err.Error())
+	}
+	_, err = cli.WaitForTx(context.Background(), createTx)
+	if err != nil {
+		return fmt.Errorf("wait for create archive object tx error, object=%s, err=%s", objectName, err.Error())
+	}
+
+	err = cli.PutObject(context.Background(), a.bucketName, objectName, int64(len(dataBuf)),
+		bytes.NewReader(dataBuf), types.PutObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("put archive object error, object=%s, err=%s", objectName, err.Error())
+	}
+
+	util.Logger.Infof("archive uploaded, bucket=%s, object=%s", a.bucketName, objectName)
+	return nil
+}
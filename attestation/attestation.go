@@ -0,0 +1,124 @@
+package attestation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// Object is an object used or produced by the execution and the sha256 hash of its content
+type Object struct {
+	ObjectId string `json:"object_id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Hash     string `json:"hash"`
+}
+
+// Document is the attestation of an execution, it tells which code the provider ran on which inputs,
+// and what the execution produced
+type Document struct {
+	TaskId             int64    `json:"task_id"`
+	Provider           string   `json:"provider"` // address of the key signing the document
+	Executable         Object   `json:"executable"`
+	Inputs             []Object `json:"inputs"`
+	Outputs            []Object `json:"outputs"`
+	GasUsed            uint64   `json:"gas_used"`
+	ResultStatus       uint32   `json:"result_status"`
+	RuntimeImage       string   `json:"runtime_image"`
+	RuntimeImageDigest string   `json:"runtime_image_digest"`
+	StartTime          int64    `json:"start_time"`
+	EndTime            int64    `json:"end_time"`
+}
+
+// SignedDocument is the attestation document signed by the provider
+type SignedDocument struct {
+	Document  Document `json:"document"`
+	Signature string   `json:"signature"` // hex encoded signature of the keccak256 hash of the json encoded document
+}
+
+// Signer signs the attestation documents, e.g. the account of the provider
+type Signer interface {
+	GetAddress() sdk.AccAddress
+	Sign(unsignBytes []byte) ([]byte, error)
+}
+
+// Sign signs the document by the signer, the provider of the document is set to the address of the signer
+func Sign(doc Document, signer Signer) (*SignedDocument, error) {
+	doc.Provider = signer.GetAddress().String()
+	hash, err := documentHash(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := signer.Sign(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedDocument{Document: doc, Signature: hex.EncodeToString(sig)}, nil
+}
+
+// Verify checks the signed document is signed by its provider
+func Verify(signed *SignedDocument) error {
+	hash, err := documentHash(signed.Document)
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(signed.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err.Error())
+	}
+
+	pubKey, err := ethcrypto.SigToPub(hash, sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err.Error())
+	}
+	if !ethcommon.IsHexAddress(signed.Document.Provider) {
+		return fmt.Errorf("invalid provider address %s", signed.Document.Provider)
+	}
+	if ethcrypto.PubkeyToAddress(*pubKey) != ethcommon.HexToAddress(signed.Document.Provider) {
+		return errors.New("document is not signed by the provider")
+	}
+	return nil
+}
+
+// Decode decodes the signed document uploaded along with the results, and verifies its signature
+func Decode(data []byte) (*SignedDocument, error) {
+	var signed SignedDocument
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, err
+	}
+	if err := Verify(&signed); err != nil {
+		return nil, err
+	}
+	return &signed, nil
+}
+
+// HashFile returns the hex encoded sha256 hash of the file
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func documentHash(doc Document) ([]byte, error) {
+	bz, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return ethcrypto.Keccak256(bz), nil
+}
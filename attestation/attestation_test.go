@@ -0,0 +1,51 @@
+package attestation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdktypes "github.com/bnb-chain/greenfield-go-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	account, _, err := sdktypes.NewAccount("provider")
+	require.NoError(t, err)
+	other, _, err := sdktypes.NewAccount("other")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "result.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+	hash, err := HashFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", hash)
+
+	doc := Document{
+		TaskId:     1,
+		Executable: Object{ObjectId: "10", Hash: "aa"},
+		Inputs:     []Object{{ObjectId: "11", Hash: "bb"}},
+		Outputs:    []Object{{Name: "result.txt", Hash: hash}},
+		GasUsed:    100,
+	}
+	signed, err := Sign(doc, account)
+	require.NoError(t, err)
+	require.Equal(t, account.GetAddress().String(), signed.Document.Provider)
+
+	data, err := json.Marshal(signed)
+	require.NoError(t, err)
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, signed, decoded)
+
+	// tampered document
+	tampered := *signed
+	tampered.Document.GasUsed = 1
+	require.Error(t, Verify(&tampered))
+
+	// signed by another key
+	tampered = *signed
+	tampered.Document.Provider = other.GetAddress().String()
+	require.Error(t, Verify(&tampered))
+}
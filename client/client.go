@@ -2,53 +2,248 @@ package client
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/hex"
-	"strconv"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	tmtypes "github.com/cometbft/cometbft/types"
 
 	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/event"
 	"github.com/bnb-chain/greenfield-execution-provider/model"
 	"github.com/bnb-chain/greenfield-execution-provider/util"
-	sdkclient "github.com/bnb-chain/greenfield-go-sdk/client"
-	sdktypes "github.com/bnb-chain/greenfield-go-sdk/types"
 	"github.com/bnb-chain/greenfield/sdk/client"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+const (
+	subscriberName     = "execution-provider"
+	headObjectByIdPath = "/greenfield.storage.Query/HeadObjectById"
 )
 
 type GreenfieldClient struct {
 	config    *util.GreenfieldConfig
-	sdkClient sdkclient.Client
-	tmClient  client.TendermintClient
+	endpoints *EndpointPool
+	registry  *event.Registry
+}
+
+// NewGreenFieldClient returns the greenfield client, the events registered in the registry are
+// decoded from blocks
+func NewGreenFieldClient(cfg *util.GreenfieldConfig, registry *event.Registry) *GreenfieldClient {
+	return &GreenfieldClient{
+		config:    cfg,
+		endpoints: NewEndpointPool(cfg.GetRPCAddrs()),
+		registry:  registry,
+	}
+}
+
+// StartHealthCheck starts the routine for checking the health of rpc endpoints
+func (c *GreenfieldClient) StartHealthCheck() {
+	c.endpoints.StartHealthCheck()
 }
 
-func NewGreenFieldClient(cfg *util.GreenfieldConfig) *GreenfieldClient {
-	var account *sdktypes.Account = nil
-	var err error
-	if cfg.PrivateKey != "" {
-		account, err = sdktypes.NewAccountFromPrivateKey("sender", cfg.PrivateKey)
+// Reachable returns an error if no rpc endpoint is reachable
+func (c *GreenfieldClient) Reachable() error {
+	return c.endpoints.Reachable()
+}
+
+// call sends the request to the endpoints in order of health until it succeeds, and returns the
+// address of the endpoint serving the request
+func (c *GreenfieldClient) call(request func(tmClient client.TendermintClient) error) (string, error) {
+	return c.endpoints.Call(request)
+}
+
+// GetLatestHeight returns the latest block height of the healthiest endpoint
+func (c *GreenfieldClient) GetLatestHeight() (int64, error) {
+	var latestHeight int64
+	_, err := c.call(func(tmClient client.TendermintClient) error {
+		status, err := tmClient.TmClient.Status(context.Background())
 		if err != nil {
-			panic(err)
+			return err
 		}
+		latestHeight = status.SyncInfo.LatestBlockHeight
+		return nil
+	})
+	return latestHeight, err
+}
+
+// SubscribeNewHeights subscribes to the new blocks and the txs with registered events over websocket,
+// the heights of them are sent to the returned channel. the subscription is stopped when the context
+// is done.
+func (c *GreenfieldClient) SubscribeNewHeights(ctx context.Context) (<-chan int64, error) {
+	// use a dedicated connection so the polling requests are not affected by the websocket
+	tmClient := client.NewTendermintClient(c.endpoints.Best()).TmClient
+	if err := tmClient.Start(); err != nil {
+		return nil, err
 	}
 
-	sdkClient, err := sdkclient.New(cfg.ChainIdString, cfg.RPCAddr, sdkclient.Option{DefaultAccount: account})
+	blockCh, err := tmClient.Subscribe(ctx, subscriberName, tmtypes.QueryForEvent(tmtypes.EventNewBlockHeader).String())
 	if err != nil {
-		panic(err)
+		tmClient.Stop()
+		return nil, err
 	}
 
-	tmClient := client.NewTendermintClient(cfg.RPCAddr)
+	// txs are filtered by the registered event types locally, for the attributes of events are unknown
+	txCh, err := tmClient.Subscribe(ctx, subscriberName, tmtypes.QueryForEvent(tmtypes.EventTx).String())
+	if err != nil {
+		tmClient.Stop()
+		return nil, err
+	}
 
-	return &GreenfieldClient{
-		config:    cfg,
-		sdkClient: sdkClient,
-		tmClient:  tmClient,
+	heights := make(chan int64)
+	go func() {
+		defer tmClient.Stop()
+		defer close(heights)
+
+		for {
+			var height int64
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-blockCh:
+				data, ok := event.Data.(tmtypes.EventDataNewBlockHeader)
+				if !ok {
+					continue
+				}
+				height = data.Header.Height
+			case event := <-txCh:
+				data, ok := event.Data.(tmtypes.EventDataTx)
+				if !ok || !c.hasRegisteredEvent(data.Result.Events) {
+					continue
+				}
+				util.Logger.Debugf("execution tx subscribed, height=%d, txHash=%s", data.Height,
+					strings.ToUpper(hex.EncodeToString(tmtypes.Tx(data.Tx).Hash())))
+				height = data.Height
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case heights <- height:
+			}
+		}
+	}()
+	return heights, nil
+}
+
+// GetObjectBucketName returns the bucket name of the object
+func (c *GreenfieldClient) GetObjectBucketName(objectId string) (string, error) {
+	request := storagetypes.QueryHeadObjectByIdRequest{ObjectId: objectId}
+	data, err := request.Marshal()
+	if err != nil {
+		return "", err
+	}
+
+	var response storagetypes.QueryHeadObjectResponse
+	_, err = c.call(func(tmClient client.TendermintClient) error {
+		result, err := tmClient.TmClient.ABCIQuery(context.Background(), headObjectByIdPath, data)
+		if err != nil {
+			return err
+		}
+		if result.Response.Code != 0 {
+			return fmt.Errorf("query object error, code=%d, log=%s", result.Response.Code, result.Response.Log)
+		}
+		return response.Unmarshal(result.Response.Value)
+	})
+	if err != nil {
+		return "", err
 	}
+	if response.ObjectInfo == nil {
+		return "", fmt.Errorf("object not found, object_id=%s", objectId)
+	}
+	return response.ObjectInfo.BucketName, nil
+}
+
+func (c *GreenfieldClient) hasRegisteredEvent(events []abci.Event) bool {
+	for _, ev := range events {
+		if c.registry.IsRegistered(ev.Type) {
+			return true
+		}
+	}
+	return false
 }
 
+// GetBlockAndEventsAtHeight returns the block and the execution events at the given height, the block
+// hash is cross-checked with other endpoints if enabled
 func (c *GreenfieldClient) GetBlockAndEventsAtHeight(height int64) (*common.BlockAndEventLogs, error) {
+	var result *common.BlockAndEventLogs
+	addr, err := c.call(func(tmClient client.TendermintClient) error {
+		var err error
+		result, err = getBlockAndEventsAtHeight(tmClient, c.registry, height)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.config.CrossCheckBlockHash {
+		if err := c.crossCheckBlockHash(addr, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// crossCheckBlockHash compares the block hash served by the given endpoint with other endpoints to
+// detect a lying endpoint. one agreeing endpoint is enough, otherwise all endpoints are asked and the
+// hash of the majority wins, the endpoints disagreeing with the majority are suspended.
+func (c *GreenfieldClient) crossCheckBlockHash(addr string, block *common.BlockAndEventLogs) error {
+	hashes := map[string]string{addr: block.BlockHash}
+	for _, other := range c.endpoints.Select() {
+		if other == addr {
+			continue
+		}
+
+		start := time.Now()
+		header, err := c.endpoints.TmClient(other).TmClient.Header(context.Background(), &block.Height)
+		c.endpoints.Report(other, time.Since(start), err)
+		if err != nil {
+			util.Logger.Errorf("get block header error, addr=%s, height=%d, err=%s", other, block.Height, err.Error())
+			continue
+		}
+
+		hashes[other] = header.Header.Hash().String()
+		if len(hashes) == 2 && hashes[other] == block.BlockHash {
+			return nil
+		}
+	}
+
+	votes := make(map[string]int)
+	for _, hash := range hashes {
+		votes[hash]++
+	}
+	var majorityHash string
+	for hash, vote := range votes {
+		if vote*2 > len(hashes) {
+			majorityHash = hash
+		}
+	}
+	if majorityHash == "" {
+		msg := fmt.Sprintf("block hashes of rpc endpoints mismatch, height=%d, hashes=%v", block.Height, hashes)
+		util.SendSlackMessage(msg)
+		return errors.New(msg)
+	}
+
+	for endpoint, hash := range hashes {
+		if hash != majorityHash {
+			c.endpoints.Suspend(endpoint, fmt.Sprintf("wrong block hash, height=%d, hash=%s, majority hash=%s", block.Height, hash, majorityHash))
+			util.SendSlackMessage(fmt.Sprintf("rpc endpoint %s served wrong block hash, height=%d, hash=%s, majority hash=%s",
+				endpoint, block.Height, hash, majorityHash))
+		}
+	}
+	if block.BlockHash != majorityHash {
+		return fmt.Errorf("wrong block hash served by %s, height=%d", addr, block.Height)
+	}
+	return nil
+}
+
+func getBlockAndEventsAtHeight(tmClient client.TendermintClient, registry *event.Registry, height int64) (*common.BlockAndEventLogs, error) {
 	result := &common.BlockAndEventLogs{}
 
-	block, err := c.tmClient.TmClient.Block(context.Background(), &height)
+	block, err := tmClient.TmClient.Block(context.Background(), &height)
 	if err != nil {
 		return nil, err
 	}
@@ -57,80 +252,34 @@ func (c *GreenfieldClient) GetBlockAndEventsAtHeight(height int64) (*common.Bloc
 	result.ParentBlockHash = block.Block.LastBlockID.Hash.String()
 	result.BlockTime = block.Block.Time.Unix()
 
-	blockResults, err := c.tmClient.TmClient.BlockResults(context.Background(), &height)
+	blockResults, err := tmClient.TmClient.BlockResults(context.Background(), &height)
 	if err != nil {
 		return nil, err
 	}
 
 	for idx, tx := range blockResults.TxsResults {
-		for _, event := range tx.Events {
-			switch event.Type {
-			case common.ExecutionTaskEvent:
-				eventLog := &model.EventLog{
-					EventName: common.ExecutionTaskEvent,
-					BlockHash: result.BlockHash,
-					TxHash:    strings.ToUpper(hex.EncodeToString(block.Block.Txs[idx].Hash())),
-					Height:    result.Height,
-				}
-
-				for _, attr := range event.Attributes {
-					switch attr.Key {
-					case "task_id":
-						taskId, err := util.QuotedStrToIntWithBitSize(attr.Value, 64)
-						if err != nil {
-							return nil, err
-						}
-						eventLog.TaskId = taskId
-					case "operator":
-						operator, err := strconv.Unquote(attr.Value)
-						if err != nil {
-							return nil, err
-						}
-						eventLog.Operator = operator
-					case "executable_object_id":
-						executableObjectId, err := strconv.Unquote(attr.Value)
-						if err != nil {
-							return nil, err
-						}
-						eventLog.ExecutableObjectId = executableObjectId
-					case "input_object_ids":
-						//todo: process this
-						eventLog.InputObjectIds = attr.Value
-					case "max_gas":
-						maxGas, err := strconv.Unquote(attr.Value)
-						if err != nil {
-							return nil, err
-						}
-						eventLog.MaxGas = maxGas
-					case "method":
-						method, err := strconv.Unquote(attr.Value)
-						if err != nil {
-							return nil, err
-						}
-						eventLog.Method = method
-					case "params":
-						params, err := strconv.Unquote(attr.Value)
-						if err != nil {
-							return nil, err
-						}
-						bts, err := base64.StdEncoding.DecodeString(params)
-						if err != nil {
-							return nil, err
-						}
-						eventLog.Params = hex.EncodeToString(bts)
-					}
-				}
+		for _, ev := range tx.Events {
+			registration, ok := registry.Get(ev.Type)
+			if !ok {
+				continue
+			}
 
-				result.Events = append(result.Events, eventLog)
-			case common.ExecutionResultEvent:
-				eventLog := &model.EventLog{
-					EventName: common.ExecutionResultEvent,
-					BlockHash: result.BlockHash,
-					TxHash:    strings.ToUpper(hex.EncodeToString(block.Block.Txs[idx].Hash())),
-					Height:    result.Height,
+			eventLog := &model.EventLog{
+				EventName: ev.Type,
+				BlockHash: result.BlockHash,
+				TxHash:    strings.ToUpper(hex.EncodeToString(block.Block.Txs[idx].Hash())),
+				Height:    result.Height,
+			}
+			if registration.Decoder != nil {
+				if err := registration.Decoder(eventLog, ev.Attributes); err != nil {
+					return nil, err
 				}
-				result.Events = append(result.Events, eventLog)
 			}
+			if registration.Filter != nil && !registration.Filter(eventLog) {
+				continue
+			}
+
+			result.Events = append(result.Events, eventLog)
 		}
 	}
 
@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield/sdk/client"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// Endpoint is a greenfield rpc endpoint and its health score
+type Endpoint struct {
+	Addr           string
+	Latency        time.Duration // moving average of the request latency
+	Height         int64         // latest height reported by the health check
+	Failures       int           // consecutive failed requests
+	SuspendedUntil time.Time     // the endpoint is not used until then, e.g. it served a wrong block
+}
+
+// EndpointPool keeps the health of the rpc endpoints and selects the healthiest ones to use
+type EndpointPool struct {
+	mtx       sync.RWMutex
+	endpoints []*Endpoint
+	tmClients map[string]client.TendermintClient
+}
+
+// NewEndpointPool returns the endpoint pool of the given rpc addresses
+func NewEndpointPool(addrs []string) *EndpointPool {
+	pool := &EndpointPool{
+		tmClients: make(map[string]client.TendermintClient),
+	}
+	for _, addr := range addrs {
+		pool.endpoints = append(pool.endpoints, &Endpoint{Addr: addr})
+		pool.tmClients[addr] = client.NewTendermintClient(addr)
+	}
+	return pool
+}
+
+// TmClient returns the tendermint client of the given endpoint
+func (p *EndpointPool) TmClient(addr string) client.TendermintClient {
+	return p.tmClients[addr]
+}
+
+// Endpoints returns a snapshot of the endpoints
+func (p *EndpointPool) Endpoints() []Endpoint {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	endpoints := make([]Endpoint, 0, len(p.endpoints))
+	for _, endpoint := range p.endpoints {
+		endpoints = append(endpoints, *endpoint)
+	}
+	return endpoints
+}
+
+// Select returns the addresses of the endpoints ordered by health. healthy endpoints go first and are
+// ordered by latency, unhealthy ones are kept at the end as the last resort.
+func (p *EndpointPool) Select() []string {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	healthy := p.healthyFunc()
+
+	endpoints := make([]*Endpoint, len(p.endpoints))
+	copy(endpoints, p.endpoints)
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if healthy(endpoints[i]) != healthy(endpoints[j]) {
+			return healthy(endpoints[i])
+		}
+		return endpoints[i].Latency < endpoints[j].Latency
+	})
+
+	addrs := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		addrs = append(addrs, endpoint.Addr)
+	}
+	return addrs
+}
+
+// Reachable returns an error if none of the endpoints passed the last health check and served the recent requests
+func (p *EndpointPool) Reachable() error {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	now := time.Now()
+	for _, endpoint := range p.endpoints {
+		if endpoint.Height > 0 && endpoint.Failures < common.EndpointMaxFailures && now.After(endpoint.SuspendedUntil) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no reachable rpc endpoint of %d", len(p.endpoints))
+}
+
+// healthyFunc returns whether an endpoint is healthy, i.e. it serves the requests, it is not suspended and it is
+// not lagging behind the others. the lock should be held
+func (p *EndpointPool) healthyFunc() func(endpoint *Endpoint) bool {
+	var maxHeight int64
+	for _, endpoint := range p.endpoints {
+		if endpoint.Height > maxHeight {
+			maxHeight = endpoint.Height
+		}
+	}
+
+	now := time.Now()
+	return func(endpoint *Endpoint) bool {
+		return endpoint.Failures < common.EndpointMaxFailures &&
+			now.After(endpoint.SuspendedUntil) &&
+			endpoint.Height+common.EndpointMaxLagBlocks >= maxHeight
+	}
+}
+
+// Healthy returns whether the given endpoint is healthy
+func (p *EndpointPool) Healthy(addr string) bool {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	healthy := p.healthyFunc()
+	for _, endpoint := range p.endpoints {
+		if endpoint.Addr == addr {
+			return healthy(endpoint)
+		}
+	}
+	return false
+}
+
+// Best returns the address of the healthiest endpoint
+func (p *EndpointPool) Best() string {
+	return p.Select()[0]
+}
+
+// Call sends the request to the endpoints in order of health until it succeeds, and returns the
+// address of the endpoint serving the request
+func (p *EndpointPool) Call(request func(tmClient client.TendermintClient) error) (string, error) {
+	var err error
+	for _, addr := range p.Select() {
+		start := time.Now()
+		err = request(p.TmClient(addr))
+		p.Report(addr, time.Since(start), err)
+		if err == nil {
+			return addr, nil
+		}
+		util.Logger.Errorf("rpc request error, try next endpoint, addr=%s, err=%s", addr, err.Error())
+	}
+	return "", err
+}
+
+// Report records the result of a request to the given endpoint
+func (p *EndpointPool) Report(addr string, latency time.Duration, err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, endpoint := range p.endpoints {
+		if endpoint.Addr != addr {
+			continue
+		}
+		if err != nil {
+			endpoint.Failures++
+			return
+		}
+		endpoint.Failures = 0
+		if endpoint.Latency == 0 {
+			endpoint.Latency = latency
+		} else {
+			endpoint.Latency = (endpoint.Latency*4 + latency) / 5
+		}
+		return
+	}
+}
+
+// Suspend stops using the given endpoint for a while
+func (p *EndpointPool) Suspend(addr string, reason string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, endpoint := range p.endpoints {
+		if endpoint.Addr == addr {
+			endpoint.SuspendedUntil = time.Now().Add(common.EndpointSuspendDuration)
+		}
+	}
+	util.Logger.Errorf("rpc endpoint suspended, addr=%s, reason=%s", addr, reason)
+}
+
+// CheckHealth queries the status of all endpoints and updates their heights and latencies
+func (p *EndpointPool) CheckHealth() {
+	var wg sync.WaitGroup
+	for addr, tmClient := range p.tmClients {
+		wg.Add(1)
+		go func(addr string, tmClient client.TendermintClient) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), common.EndpointHealthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			status, err := tmClient.TmClient.Status(ctx)
+			p.Report(addr, time.Since(start), err)
+			if err != nil {
+				util.Logger.Errorf("rpc endpoint health check error, addr=%s, err=%s", addr, err.Error())
+				return
+			}
+
+			p.mtx.Lock()
+			for _, endpoint := range p.endpoints {
+				if endpoint.Addr == addr {
+					endpoint.Height = status.SyncInfo.LatestBlockHeight
+				}
+			}
+			p.mtx.Unlock()
+		}(addr, tmClient)
+	}
+	wg.Wait()
+}
+
+// StartHealthCheck starts the routine for checking the health of endpoints periodically
+func (p *EndpointPool) StartHealthCheck() {
+	p.CheckHealth()
+	go func() {
+		for {
+			time.Sleep(common.EndpointHealthCheckInterval)
+			p.CheckHealth()
+		}
+	}()
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+)
+
+func TestEndpointPoolSelect(t *testing.T) {
+	pool := NewEndpointPool([]string{"http://a:26657", "http://b:26657", "http://c:26657"})
+
+	pool.Report("http://a:26657", 30*time.Millisecond, nil)
+	pool.Report("http://b:26657", 10*time.Millisecond, nil)
+	pool.Report("http://c:26657", 20*time.Millisecond, nil)
+	require.Equal(t, []string{"http://b:26657", "http://c:26657", "http://a:26657"}, pool.Select())
+
+	// failing endpoints go last
+	for i := 0; i < common.EndpointMaxFailures; i++ {
+		pool.Report("http://b:26657", 0, errors.New("connection refused"))
+	}
+	require.Equal(t, []string{"http://c:26657", "http://a:26657", "http://b:26657"}, pool.Select())
+
+	// a success resets the failures
+	pool.Report("http://b:26657", 10*time.Millisecond, nil)
+	require.Equal(t, "http://b:26657", pool.Best())
+
+	// lagging endpoints go last
+	pool.mtx.Lock()
+	pool.endpoints[0].Height = 100
+	pool.endpoints[1].Height = 100 - common.EndpointMaxLagBlocks - 1
+	pool.endpoints[2].Height = 100
+	pool.mtx.Unlock()
+	require.Equal(t, []string{"http://c:26657", "http://a:26657", "http://b:26657"}, pool.Select())
+
+	pool.Suspend("http://c:26657", "wrong block hash")
+	require.Equal(t, []string{"http://a:26657", "http://b:26657", "http://c:26657"}, pool.Select())
+}
+
+func TestEndpointPoolReachable(t *testing.T) {
+	pool := NewEndpointPool([]string{"http://a:26657", "http://b:26657"})
+	// not health checked yet
+	require.Error(t, pool.Reachable())
+
+	pool.mtx.Lock()
+	pool.endpoints[0].Height = 100
+	pool.mtx.Unlock()
+	require.NoError(t, pool.Reachable())
+
+	for i := 0; i < common.EndpointMaxFailures; i++ {
+		pool.Report("http://a:26657", 0, errors.New("connection refused"))
+	}
+	require.EqualError(t, pool.Reachable(), "no reachable rpc endpoint of 2")
+
+	pool.Report("http://a:26657", 10*time.Millisecond, nil)
+	pool.Suspend("http://a:26657", "wrong block hash")
+	require.Error(t, pool.Reachable())
+}
+
+func TestEndpointPoolHealthy(t *testing.T) {
+	pool := NewEndpointPool([]string{"http://a:26657", "http://b:26657"})
+	require.True(t, pool.Healthy("http://a:26657"))
+	require.False(t, pool.Healthy("http://c:26657"))
+
+	for i := 0; i < common.EndpointMaxFailures; i++ {
+		pool.Report("http://a:26657", 0, errors.New("connection refused"))
+	}
+	require.False(t, pool.Healthy("http://a:26657"))
+	require.True(t, pool.Healthy("http://b:26657"))
+
+	pool.Suspend("http://b:26657", "wrong block hash")
+	require.False(t, pool.Healthy("http://b:26657"))
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"cosmossdk.io/math"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield/sdk/client"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+const executionResultStorePath = "/store/" + storagetypes.StoreKey + "/key"
+
+// ExecutionResult is the execution result of a task on chain
+type ExecutionResult struct {
+	Status    uint32
+	Submitter string // empty if the tx submitting the result is not found, e.g. the tx index of the node is disabled
+	TxHash    string
+	Height    int64
+}
+
+// GetExecutionResult returns the execution result of the task on chain, nil if no result is submitted.
+// the chain has no query for execution results, so the result is read from the store of the storage module,
+// and the submitter is found by searching the tx emitting the result event
+func (c *SDKClients) GetExecutionResult(ctx context.Context, taskId int64) (*ExecutionResult, error) {
+	var result *ExecutionResult
+	_, err := c.endpoints.Call(func(tmClient client.TendermintClient) error {
+		res, err := tmClient.TmClient.ABCIQuery(ctx, executionResultStorePath, storagetypes.GetExecutionResultKey(math.NewUint(uint64(taskId))))
+		if err != nil {
+			return err
+		}
+		if res.Response.Code != 0 {
+			return fmt.Errorf("query execution result error, code=%d, log=%s", res.Response.Code, res.Response.Log)
+		}
+		if len(res.Response.Value) == 0 {
+			result = nil
+			return nil
+		}
+
+		var executionResult storagetypes.ExecutionResult
+		if err := executionResult.Unmarshal(res.Response.Value); err != nil {
+			return err
+		}
+		result = &ExecutionResult{Status: executionResult.Status}
+
+		query := fmt.Sprintf("%s.task_id='%s'", common.ExecutionResultEvent, strconv.Quote(strconv.FormatInt(taskId, 10)))
+		txs, err := tmClient.TmClient.TxSearch(ctx, query, false, nil, nil, "asc")
+		if err != nil {
+			// the result exists anyway
+			return nil
+		}
+		result.Submitter, result.TxHash, result.Height = executionResultSubmitter(txs.Txs, taskId)
+		return nil
+	})
+	return result, err
+}
+
+// executionResultSubmitter returns the submitter, the hash and the height of the tx submitting the result of the task
+func executionResultSubmitter(txs []*ctypes.ResultTx, taskId int64) (string, string, int64) {
+	taskIdValue := strconv.Quote(strconv.FormatInt(taskId, 10))
+	for _, tx := range txs {
+		if tx.TxResult.Code != 0 {
+			continue
+		}
+		for _, ev := range tx.TxResult.Events {
+			if ev.Type != common.ExecutionResultEvent {
+				continue
+			}
+
+			var matched bool
+			var operator string
+			for _, attr := range ev.Attributes {
+				switch attr.Key {
+				case "task_id":
+					matched = attr.Value == taskIdValue
+				case "operator":
+					operator, _ = strconv.Unquote(attr.Value)
+				}
+			}
+			if matched {
+				return operator, tx.Hash.String(), tx.Height
+			}
+		}
+	}
+	return "", "", 0
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+)
+
+func resultTx(hash []byte, height int64, code uint32, taskIds ...string) *ctypes.ResultTx {
+	tx := &ctypes.ResultTx{Hash: hash, Height: height, TxResult: abci.ResponseDeliverTx{Code: code}}
+	for _, taskId := range taskIds {
+		tx.TxResult.Events = append(tx.TxResult.Events, abci.Event{
+			Type: common.ExecutionResultEvent,
+			Attributes: []abci.EventAttribute{
+				{Key: "operator", Value: `"0xabc"`},
+				{Key: "task_id", Value: `"` + taskId + `"`},
+			},
+		})
+	}
+	return tx
+}
+
+func TestExecutionResultSubmitter(t *testing.T) {
+	txs := []*ctypes.ResultTx{
+		resultTx([]byte{0x01}, 10, 1, "5"), // failed tx
+		resultTx([]byte{0x02}, 11, 0, "4", "5"),
+	}
+
+	submitter, txHash, height := executionResultSubmitter(txs, 5)
+	require.Equal(t, "0xabc", submitter)
+	require.Equal(t, "02", txHash)
+	require.Equal(t, int64(11), height)
+
+	submitter, _, _ = executionResultSubmitter(txs, 6)
+	require.Equal(t, "", submitter)
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	sdkclient "github.com/bnb-chain/greenfield-go-sdk/client"
+	sdktypes "github.com/bnb-chain/greenfield-go-sdk/types"
+
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// SDKClients holds a greenfield sdk client for each rpc endpoint, and returns the client of the
+// healthiest endpoint
+type SDKClients struct {
+	endpoints *EndpointPool
+	clients   map[string]sdkclient.Client
+}
+
+func NewSDKClients(cfg *util.GreenfieldConfig, account *sdktypes.Account) *SDKClients {
+	addrs := cfg.GetRPCAddrs()
+	clients := make(map[string]sdkclient.Client)
+	for _, addr := range addrs {
+		sdkClient, err := sdkclient.New(cfg.ChainIdString, addr, sdkclient.Option{DefaultAccount: account})
+		if err != nil {
+			panic(err)
+		}
+		clients[addr] = sdkClient
+	}
+
+	return &SDKClients{
+		endpoints: NewEndpointPool(addrs),
+		clients:   clients,
+	}
+}
+
+// StartHealthCheck starts the routine for checking the health of rpc endpoints
+func (c *SDKClients) StartHealthCheck() {
+	c.endpoints.StartHealthCheck()
+}
+
+// Reachable returns an error if no rpc endpoint is reachable
+func (c *SDKClients) Reachable() error {
+	return c.endpoints.Reachable()
+}
+
+// Client returns the sdk client of the healthiest endpoint
+func (c *SDKClients) Client() sdkclient.Client {
+	return c.clients[c.endpoints.Best()]
+}
+
+// Best returns the address of the healthiest endpoint
+func (c *SDKClients) Best() string {
+	return c.endpoints.Best()
+}
+
+// Healthy returns whether the given endpoint is healthy
+func (c *SDKClients) Healthy(addr string) bool {
+	return c.endpoints.Healthy(addr)
+}
+
+// ClientOf returns the sdk client of the given endpoint, e.g. the endpoint the txs of an account are pinned to
+func (c *SDKClients) ClientOf(addr string) sdkclient.Client {
+	return c.clients[addr]
+}
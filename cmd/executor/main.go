@@ -3,17 +3,21 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/http"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"github.com/bnb-chain/greenfield-execution-provider/api"
+	"github.com/bnb-chain/greenfield-execution-provider/client"
 	"github.com/bnb-chain/greenfield-execution-provider/executor"
 	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
 	"github.com/bnb-chain/greenfield-execution-provider/util"
-	sdkclient "github.com/bnb-chain/greenfield-go-sdk/client"
 	"github.com/bnb-chain/greenfield-go-sdk/types"
 )
 
@@ -60,19 +64,28 @@ func main() {
 		panic(fmt.Sprintf("open db error, err=%s", err.Error()))
 	}
 	defer db.Close()
-	model.InitTables(db)
+	if err := model.Migrate(db); err != nil {
+		panic(fmt.Sprintf("migrate db error, err=%s", err.Error()))
+	}
 
 	account, err := types.NewAccountFromMnemonic("executor", config.GreenfieldConfig.PrivateKey)
 	if err != nil {
 		panic(err)
 	}
 
-	sdkClient, err := sdkclient.New(config.GreenfieldConfig.ChainIdString, config.GreenfieldConfig.RPCAddr, sdkclient.Option{DefaultAccount: account})
-	if err != nil {
-		panic(err)
+	sdkClients := client.NewSDKClients(&config.GreenfieldConfig, account)
+	sdkClients.StartHealthCheck()
+
+	st := store.NewSQLStore(db)
+	executor := executor.NewExecutor(st, config, sdkClients)
+	if config.HTTPConfig != nil {
+		server := api.NewServer(config.HTTPConfig, st)
+		server.AddHealthChecker(executor)
+		server.HandleJSON("/queue", func(r *http.Request) (interface{}, error) {
+			return executor.Scheduler.QueueDepths(st)
+		})
+		server.Start()
 	}
-
-	executor := executor.NewExecutor(db, sdkClient)
 	executor.Start()
 
 	select {}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+const (
+	flagConfigPath = "config-path"
+)
+
+func initFlags() {
+	flag.String(flagConfigPath, "", "config path of any component, only the db config is used")
+
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+	pflag.Parse()
+	err := viper.BindPFlags(pflag.CommandLine)
+	if err != nil {
+		panic(fmt.Sprintf("bind flags error, err=%s", err))
+	}
+}
+
+func printUsage() {
+	fmt.Print("usage: ./migrate --config-path config_file_path\n")
+}
+
+// migrate applies the schema migrations and exits, the components apply them at startup as well
+func main() {
+	initFlags()
+
+	configFilePath := viper.GetString(flagConfigPath)
+	if configFilePath == "" {
+		printUsage()
+		return
+	}
+	config := util.ParseDBConfigFromFile(configFilePath)
+	config.Validate()
+
+	db, err := gorm.Open(config.Dialect, config.DBPath)
+	if err != nil {
+		panic(fmt.Sprintf("open db error, err=%s", err.Error()))
+	}
+	defer db.Close()
+
+	var from int
+	if db.HasTable(&model.SchemaVersion{}) {
+		if from, err = model.CurrentSchemaVersion(db); err != nil {
+			panic(fmt.Sprintf("get schema version error, err=%s", err.Error()))
+		}
+	}
+	if err := model.Migrate(db); err != nil {
+		panic(fmt.Sprintf("migrate db error, err=%s", err.Error()))
+	}
+	to, err := model.CurrentSchemaVersion(db)
+	if err != nil {
+		panic(fmt.Sprintf("get schema version error, err=%s", err.Error()))
+	}
+	fmt.Printf("schema migrated from version %d to %d\n", from, to)
+}
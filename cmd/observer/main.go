@@ -3,17 +3,24 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/http"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"github.com/bnb-chain/greenfield-execution-provider/api"
+	"github.com/bnb-chain/greenfield-execution-provider/archive"
 	"github.com/bnb-chain/greenfield-execution-provider/client"
+	"github.com/bnb-chain/greenfield-execution-provider/event"
 	"github.com/bnb-chain/greenfield-execution-provider/model"
 	"github.com/bnb-chain/greenfield-execution-provider/observer"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
 	"github.com/bnb-chain/greenfield-execution-provider/util"
+	sdktypes "github.com/bnb-chain/greenfield-go-sdk/types"
 )
 
 const (
@@ -59,13 +66,40 @@ func main() {
 		panic(fmt.Sprintf("open db error, err=%s", err.Error()))
 	}
 	defer db.Close()
-	model.InitTables(db)
+	if err := model.Migrate(db); err != nil {
+		panic(fmt.Sprintf("migrate db error, err=%s", err.Error()))
+	}
 
-	greenfieldClient := client.NewGreenFieldClient(&config.GreenfieldConfig)
+	registry := event.NewRegistry()
+	greenfieldClient := client.NewGreenFieldClient(&config.GreenfieldConfig, registry)
+	greenfieldClient.StartHealthCheck()
+
+	var archiver archive.Archiver
+	if config.PruneConfig != nil && config.PruneConfig.ArchiveDir != "" {
+		archiver = archive.NewFileArchiver(config.PruneConfig.ArchiveDir)
+		if config.PruneConfig.ArchiveBucket != "" {
+			account, err := sdktypes.NewAccountFromMnemonic("observer", config.GreenfieldConfig.PrivateKey)
+			if err != nil {
+				panic(err)
+			}
+			sdkClients := client.NewSDKClients(&config.GreenfieldConfig, account)
+			sdkClients.StartHealthCheck()
+			archiver = archive.NewGreenfieldArchiver(config.PruneConfig.ArchiveDir, sdkClients, config.PruneConfig.ArchiveBucket)
+		}
+	}
 
-	observer := observer.NewObserver(db, config, greenfieldClient)
+	st := store.NewSQLStore(db)
+	observer := observer.NewObserver(st, config, greenfieldClient, archiver, registry)
 
 	observer.Start()
+	if config.HTTPConfig != nil {
+		server := api.NewServer(config.HTTPConfig, st)
+		server.AddHealthChecker(observer)
+		server.HandleJSON("/sync", func(r *http.Request) (interface{}, error) {
+			return observer.SyncStatus()
+		})
+		server.Start()
+	}
 
 	select {}
 }
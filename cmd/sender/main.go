@@ -3,17 +3,21 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/http"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"github.com/bnb-chain/greenfield-execution-provider/api"
+	"github.com/bnb-chain/greenfield-execution-provider/client"
 	"github.com/bnb-chain/greenfield-execution-provider/model"
 	"github.com/bnb-chain/greenfield-execution-provider/sender"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
 	"github.com/bnb-chain/greenfield-execution-provider/util"
-	sdkclient "github.com/bnb-chain/greenfield-go-sdk/client"
 	sdktypes "github.com/bnb-chain/greenfield-go-sdk/types"
 )
 
@@ -47,6 +51,7 @@ func main() {
 		return
 	}
 	config = util.ParseSenderConfigFromFile(configFilePath)
+	config.Validate()
 
 	// init logger
 	util.InitLogger(*config.LogConfig)
@@ -57,20 +62,38 @@ func main() {
 		panic(fmt.Sprintf("open db error, err=%s", err.Error()))
 	}
 	defer db.Close()
-	model.InitTables(db)
-
-	account, err := sdktypes.NewAccountFromMnemonic("sender", config.GreenfieldConfig.PrivateKey)
-	if err != nil {
-		panic(err)
+	if err := model.Migrate(db); err != nil {
+		panic(fmt.Sprintf("migrate db error, err=%s", err.Error()))
 	}
 
-	sdkClient, err := sdkclient.New(config.GreenfieldConfig.ChainIdString, config.GreenfieldConfig.RPCAddr, sdkclient.Option{DefaultAccount: account})
-	if err != nil {
-		panic(err)
+	var accounts []*sender.Account
+	for idx, privateKey := range config.GetPrivateKeys() {
+		account, err := sdktypes.NewAccountFromMnemonic(fmt.Sprintf("sender%d", idx), privateKey)
+		if err != nil {
+			panic(err)
+		}
+
+		sdkClients := client.NewSDKClients(&config.GreenfieldConfig, account)
+		sdkClients.StartHealthCheck()
+
+		senderAccount, err := sender.NewAccount(sdkClients)
+		if err != nil {
+			panic(err)
+		}
+		accounts = append(accounts, senderAccount)
 	}
 
-	snder := sender.NewSender(db, sdkClient)
+	st := store.NewSQLStore(db)
+	snder := sender.NewSender(st, config, accounts)
 	snder.Start()
+	if config.HTTPConfig != nil {
+		server := api.NewServer(config.HTTPConfig, st)
+		server.AddHealthChecker(snder)
+		server.HandleJSON("/pending", func(r *http.Request) (interface{}, error) {
+			return snder.PendingQueue()
+		})
+		server.Start()
+	}
 
 	select {}
 }
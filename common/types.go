@@ -2,6 +2,8 @@ package common
 
 import (
 	"time"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
 )
 
 const (
@@ -10,20 +12,61 @@ const (
 	ObserverAlertInterval  = 5 * time.Second
 	ObserverFetchInterval  = 2 * time.Second
 
-	SenderSendInterval = 1 * time.Second
+	ObserverSubscribeTimeout    = 30 * time.Second
+	ObserverResubscribeInterval = 5 * time.Second
+
+	SenderSendInterval            = 1 * time.Second
+	SenderTxQueryTimeout          = 10 * time.Second
+	SenderInclusionTimeout  int64 = 60 // in seconds, the receipt tx not included by then is resubmitted
+	SenderRetryBaseInterval       = 5 * time.Second
+	SenderRetryMaxInterval        = 10 * time.Minute
+	SenderReportInterval          = 10 * time.Second
+
+	EndpointHealthCheckInterval       = 10 * time.Second
+	EndpointHealthCheckTimeout        = 3 * time.Second
+	EndpointSuspendDuration           = 10 * time.Minute
+	EndpointMaxFailures               = 3
+	EndpointMaxLagBlocks        int64 = 5
+
+	ExecutorFetchInterval                = 2 * time.Second
+	ExecutorQueueReportInterval          = 30 * time.Second
+	ExecutorExecutionTimeout             = 10 * time.Minute
+	ExecutorLeaseDuration                = 20 * time.Minute // the task not executed by then can be claimed by another executor
+	DefaultConfirmNum              int64 = 15
+	DefaultCatchUpWindow           int64 = 20
+	DefaultPruneBatchSize                = 500
+	DefaultSchedulerWindow         int64 = 60
+	DefaultMaxRunningTasks               = 4
+	DefaultMaxExecutionAttempts          = 3
+	DefaultSenderBatchSize               = 10
+	DefaultSenderMaxInflightTxs          = 5
+	DefaultSenderMaxSubmitAttempts       = 10
+
+	APIDefaultListLimit = 100
+	APIMaxListLimit     = 1000
+	APIReadTimeout      = 10 * time.Second
+	APIWriteTimeout     = 30 * time.Second
 
-	ExecutorFetchInterval       = 2 * time.Second
-	DefaultConfirmNum     int64 = 15
+	HealthHeartbeatTimeout = 2 * time.Minute // the routine not beaten by then is considered wedged
+	HealthCheckTimeout     = 3 * time.Second
 )
 
+const MetricsNamespace = "execution_provider"
+
 const (
 	ExecutionTaskEvent   = "greenfield.storage.EventExecutionTask"
 	ExecutionResultEvent = "greenfield.storage.EventExecutionResult"
 )
 
 const (
-	DBDialectMysql   = "mysql"
-	DBDialectSqlite3 = "sqlite3"
+	DBDialectMysql    = "mysql"
+	DBDialectSqlite3  = "sqlite3"
+	DBDialectPostgres = "postgres"
+)
+
+const (
+	PriorityPolicyFifo   = "fifo"    // tasks are executed in the order they are invoked
+	PriorityPolicyMaxGas = "max_gas" // tasks offering more gas are executed first
 )
 
 type BlockAndEventLogs struct {
@@ -31,5 +74,5 @@ type BlockAndEventLogs struct {
 	BlockHash       string
 	ParentBlockHash string
 	BlockTime       int64
-	Events          []interface{}
+	Events          []*model.EventLog
 }
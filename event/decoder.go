@@ -0,0 +1,64 @@
+package event
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// DecodeExecutionTask decodes the attributes of EventExecutionTask
+func DecodeExecutionTask(eventLog *model.EventLog, attributes []abci.EventAttribute) error {
+	for _, attr := range attributes {
+		switch attr.Key {
+		case "task_id":
+			taskId, err := util.QuotedStrToIntWithBitSize(attr.Value, 64)
+			if err != nil {
+				return err
+			}
+			eventLog.TaskId = taskId
+		case "operator":
+			operator, err := strconv.Unquote(attr.Value)
+			if err != nil {
+				return err
+			}
+			eventLog.Operator = operator
+		case "executable_object_id":
+			executableObjectId, err := strconv.Unquote(attr.Value)
+			if err != nil {
+				return err
+			}
+			eventLog.ExecutableObjectId = executableObjectId
+		case "input_object_ids":
+			//todo: process this
+			eventLog.InputObjectIds = attr.Value
+		case "max_gas":
+			maxGas, err := strconv.Unquote(attr.Value)
+			if err != nil {
+				return err
+			}
+			eventLog.MaxGas = maxGas
+		case "method":
+			method, err := strconv.Unquote(attr.Value)
+			if err != nil {
+				return err
+			}
+			eventLog.Method = method
+		case "params":
+			params, err := strconv.Unquote(attr.Value)
+			if err != nil {
+				return err
+			}
+			bts, err := base64.StdEncoding.DecodeString(params)
+			if err != nil {
+				return err
+			}
+			eventLog.Params = hex.EncodeToString(bts)
+		}
+	}
+	return nil
+}
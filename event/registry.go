@@ -0,0 +1,80 @@
+package event
+
+import (
+	"sync"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
+)
+
+// Decoder decodes the attributes of an event into the event log
+type Decoder func(eventLog *model.EventLog, attributes []abci.EventAttribute) error
+
+// Filter returns whether the decoded event should be recorded
+type Filter func(eventLog *model.EventLog) bool
+
+// Handler handles a confirmed event, it is called in the store transaction which marks the event processed
+type Handler func(tx store.Store, eventLog *model.EventLog) error
+
+// Registration is how an event type is decoded, filtered and handled. all the fields are optional,
+// an event without decoder records the common fields only, and an event without handler is simply
+// marked processed once confirmed.
+type Registration struct {
+	Decoder Decoder
+	Filter  Filter
+	Handler Handler
+}
+
+// Registry keeps the registrations of the event types observed
+type Registry struct {
+	mtx           sync.RWMutex
+	registrations map[string]Registration
+	eventTypes    []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		registrations: make(map[string]Registration),
+	}
+}
+
+// Register registers the event type, the registration replaces the previous one of the event type
+func (r *Registry) Register(eventType string, registration Registration) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, ok := r.registrations[eventType]; !ok {
+		r.eventTypes = append(r.eventTypes, eventType)
+	}
+	r.registrations[eventType] = registration
+}
+
+// IsRegistered returns whether the event type is registered
+func (r *Registry) IsRegistered(eventType string) bool {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	_, ok := r.registrations[eventType]
+	return ok
+}
+
+// Get returns the registration of the event type
+func (r *Registry) Get(eventType string) (Registration, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	registration, ok := r.registrations[eventType]
+	return registration, ok
+}
+
+// EventTypes returns the registered event types in order of registration
+func (r *Registry) EventTypes() []string {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	eventTypes := make([]string, len(r.eventTypes))
+	copy(eventTypes, r.eventTypes)
+	return eventTypes
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,37 +17,70 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
-	"github.com/jinzhu/gorm"
 
+	"github.com/bnb-chain/greenfield-execution-provider/attestation"
+	gnfdclient "github.com/bnb-chain/greenfield-execution-provider/client"
 	"github.com/bnb-chain/greenfield-execution-provider/common"
 	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
 	"github.com/bnb-chain/greenfield-execution-provider/util"
-	sdkClient "github.com/bnb-chain/greenfield-go-sdk/client"
 	"github.com/bnb-chain/greenfield-go-sdk/types"
 )
 
+const workDir = "work" // the files of each task are kept under work/{task_id}
 const downloadDir = "download"
 const executableConfigFileName = "ExecutableConfig.json"
-
-var outputDir string
-var outputFilesName []string
-var inputDir string
-var inputFilesName []string
-
-var outputBucketName string
+const executionReportFileName = "report.json"
+const runtimeImage = "gnfdexec/gnfdexe:latest"
 
 type Executor struct {
-	DB            *gorm.DB
-	Client        sdkClient.Client
-	currentTaskId int64
-	receipt       Receipt
+	Store     store.Store
+	Config    *util.ExecutorConfig
+	Clients   *gnfdclient.SDKClients
+	Scheduler *Scheduler
+
+	scheduleHeartbeat *util.Heartbeat // beaten when the scheduler returns, it fails if the scheduler is stuck on the store
+	runs              *runTracker     // the progress of the running tasks
 }
 
 type Receipt struct {
 	gasUsed        uint64
+	status         model.ExecutionResultStatus
 	returnCode     string
 	resultObjectId string
 	logObjectId    string
+
+	// for the attestation
+	executable          attestation.Object
+	inputs              []attestation.Object
+	imageDigest         string
+	startTime           int64
+	endTime             int64
+	attestationObjectId string
+}
+
+// execution is the state of a task run. the runs overlap, so nothing of a run is kept on the executor
+type execution struct {
+	task      *model.ExecutionTask
+	dir       string // absolute dir of the files of the run, removed when the run ends
+	bucket    string // bucket of the executable, the results are uploaded to it
+	outputDir string
+	receipt   Receipt
+}
+
+func newExecution(task *model.ExecutionTask) (*execution, error) {
+	dir, err := filepath.Abs(filepath.Join(workDir, strconv.FormatInt(task.TaskId, 10)))
+	if err != nil {
+		return nil, err
+	}
+	// the files of a previous run of the task are dropped
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, downloadDir), os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &execution{task: task, dir: dir}, nil
 }
 
 type ExecutableConfig struct {
@@ -87,6 +121,27 @@ type ExecutionReport struct {
 	ResultMsg string `json:"resultMsg"`
 }
 
+const (
+	executionReportSuccess  = "Success" // the result message of iwasm for a successful execution
+	executionReportOutOfGas = "OutOfGas"
+)
+
+// executionResultStatus maps the outcome of an execution to the status submitted on chain
+func executionResultStatus(report ExecutionReport, reportErr error, timedOut bool) model.ExecutionResultStatus {
+	switch {
+	case timedOut:
+		return model.ExecutionResultStatusTimeout
+	case reportErr != nil:
+		return model.ExecutionResultStatusProviderFailure
+	case report.ResultMsg == executionReportSuccess:
+		return model.ExecutionResultStatusSuccess
+	case strings.Contains(report.ResultMsg, executionReportOutOfGas):
+		return model.ExecutionResultStatusOutOfGas
+	default:
+		return model.ExecutionResultStatusTrap
+	}
+}
+
 func unzipFile(fileName string, dst string) {
 	util.Logger.Infof("try to unzip file %s to %s\n", fileName, dst)
 	archive, err := zip.OpenReader(fileName)
@@ -128,23 +183,30 @@ func unzipFile(fileName string, dst string) {
 	}
 }
 
+// workerId returns the id of the executor process, the tasks executed by it are leased to the id
+func workerId() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "executor"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
 // NewExecutor returns the executor instance
-func NewExecutor(db *gorm.DB, client sdkClient.Client) *Executor {
+func NewExecutor(st store.Store, cfg *util.ExecutorConfig, clients *gnfdclient.SDKClients) *Executor {
 	return &Executor{
-		db,
-		client,
-		0,
-		Receipt{
-			gasUsed:        0,
-			returnCode:     "",
-			resultObjectId: "",
-			logObjectId:    "",
-		},
+		st,
+		cfg,
+		clients,
+		NewScheduler(cfg.SchedulerConfig, workerId()),
+		util.NewHeartbeat(common.HealthHeartbeatTimeout),
+		newRunTracker(),
 	}
 }
 
 // Start starts the routines of executor
 func (ex *Executor) Start() {
+	go ex.Scheduler.ReportQueueDepths(ex.Store)
 	for {
 		time.Sleep(common.ExecutorFetchInterval)
 		go ex.tryInvokeExecuteTask()
@@ -152,30 +214,60 @@ func (ex *Executor) Start() {
 }
 
 func (ex *Executor) tryInvokeExecuteTask() {
-	// 1. load executeTask from db, compare the taskID
-	executionTask := model.ExecutionTask{}
-	err := ex.DB.Model(&model.ExecutionTask{}).Where("status = ? and task_id > ?", model.ExecutionTaskStatusStatusInit,
-		ex.currentTaskId).Order("task_id asc").Take(&executionTask).Error
+	// 1. pick the next executeTask by the scheduler
+	executionTask, err := ex.Scheduler.Next(ex.Store)
+	ex.scheduleHeartbeat.Beat()
 	if err != nil {
 		util.Logger.Error("tryInvokeExecuteTake error " + err.Error())
 		return
 	} else {
 		util.Logger.Error("find executionTask: " + executionTask.ExecutionObjectId)
 	}
-	ex.currentTaskId = executionTask.TaskId
+	ex.runs.start(executionTask.TaskId)
+	phaseStart := time.Now()
+
+	executed := false
+	var run *execution
+	defer func() {
+		ex.runs.done(executionTask.TaskId)
+		if !executed {
+			ex.failTask(executionTask, err)
+		}
+		var gasUsed uint64
+		if run != nil {
+			gasUsed = run.receipt.gasUsed
+			if err := os.RemoveAll(run.dir); err != nil {
+				util.Logger.Errorf("remove work dir error, task_id=%d, err=%s", executionTask.TaskId, err.Error())
+			}
+		}
+		ex.Scheduler.Done(executionTask, executed, gasUsed)
+	}()
+	if maxAttempts := ex.Scheduler.config.GetMaxExecutionAttempts(); executionTask.ExecutionAttempts > maxAttempts {
+		// the former attempts did not finish, e.g. the executor crashed running it
+		err = fmt.Errorf("execution attempts %d exceed the max %d", executionTask.ExecutionAttempts, maxAttempts)
+		return
+	}
+	run, err = newExecution(executionTask)
+	if err != nil {
+		return
+	}
+
 	// 2. download binary and data
-	executableConfig, execDir, err := ex.downloadExecutable(executionTask.ExecutionObjectId)
+	executableConfig, execDir, err := ex.downloadExecutable(run)
 	if err != nil {
 		return
 	}
 
-	err = ex.downloadInputFiles(executionTask.InputFiles, executableConfig)
+	err = ex.downloadInputFiles(run, executableConfig)
 	if err != nil {
 		return
 	}
+	ex.addTaskEvent(executionTask.TaskId, model.TaskEventDownloaded, "")
+	phaseStart = observePhase(phaseDownload, phaseStart)
 
-	outputDir = executableConfig.Data.OutputDir
-	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+	outputDir := executableConfig.Data.OutputDir
+	run.outputDir = filepath.Join(run.dir, outputDir)
+	if err := os.MkdirAll(run.outputDir, os.ModePerm); err != nil {
 		panic(err)
 	}
 
@@ -184,15 +276,15 @@ func (ex *Executor) tryInvokeExecuteTask() {
 
 	wasmFileEnv := "WASM_FILE=" + filepath.Base(execDir) + "/" + executableConfig.Executable.WasmMainFile
 
-	inputDir = executableConfig.Data.InputDir
-	inputFilesName = executableConfig.Data.InputFiles
+	inputDir := executableConfig.Data.InputDir
+	inputFilesName := executableConfig.Data.InputFiles
 	var inputFiles string
 	for i := 0; i < len(inputFilesName); i++ {
 		inputFiles += inputDir + "/" + inputFilesName[i] + " "
 	}
 	inputFileEnv := "INPUT_FILES=" + inputFiles
 
-	outputFilesName = executableConfig.Data.OutputFiles
+	outputFilesName := executableConfig.Data.OutputFiles
 	var outputFiles string
 	for j := 0; j < len(outputFilesName); j++ {
 		util.Logger.Infof("generate output files: %s/%s\n", outputDir, outputFilesName[j])
@@ -201,14 +293,9 @@ func (ex *Executor) tryInvokeExecuteTask() {
 	outputFileEnv := "OUTPUT_FILES=" + outputFiles
 	argsEnv := []string{maxGasEnv, wasmFileEnv, inputFileEnv, outputFileEnv}
 
-	abs, err := filepath.Abs("./")
-	wasmMountDir, _ := filepath.Abs(execDir)
-	inputMountDir := abs + "/" + inputDir
-	outputMountDir := abs + "/" + outputDir
-
-	if err != nil {
-		panic(err)
-	}
+	wasmMountDir := execDir
+	inputMountDir := filepath.Join(run.dir, inputDir)
+	outputMountDir := run.outputDir
 
 	// Pull Docker image
 	ctx := context.Background()
@@ -218,7 +305,7 @@ func (ex *Executor) tryInvokeExecuteTask() {
 	}
 	defer cli.Close()
 
-	reader, err := cli.ImagePull(ctx, "gnfdexec/gnfdexe:latest", dockerTypes.ImagePullOptions{})
+	reader, err := cli.ImagePull(ctx, runtimeImage, dockerTypes.ImagePullOptions{})
 	if err != nil {
 		panic(err)
 	}
@@ -226,6 +313,15 @@ func (ex *Executor) tryInvokeExecuteTask() {
 	defer reader.Close()
 	io.Copy(os.Stdout, reader)
 
+	imageInfo, _, err := cli.ImageInspectWithRaw(ctx, runtimeImage)
+	if err != nil {
+		panic(err)
+	}
+	run.receipt.imageDigest = imageInfo.ID
+	if len(imageInfo.RepoDigests) > 0 {
+		run.receipt.imageDigest = imageInfo.RepoDigests[0]
+	}
+
 	resp, err := cli.ContainerCreate(ctx, &container.Config{
 		Image: "gnfdexec/gnfdexe",
 		Env:   argsEnv,
@@ -253,51 +349,121 @@ func (ex *Executor) tryInvokeExecuteTask() {
 		panic(err)
 	}
 	util.Logger.Infof("start Container " + resp.ID)
+	run.receipt.startTime = time.Now().Unix()
 	if err := cli.ContainerStart(ctx, resp.ID, dockerTypes.ContainerStartOptions{}); err != nil {
 		util.Logger.Errorf(err.Error())
 		panic(err)
 	}
 	util.Logger.Infof("wait Container " + resp.ID)
-	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	timedOut := false
+	waitCtx, cancel := context.WithTimeout(ctx, common.ExecutorExecutionTimeout)
+	defer cancel()
+	statusCh, errCh := cli.ContainerWait(waitCtx, resp.ID, container.WaitConditionNotRunning)
 	select {
 	case err := <-errCh:
 		if err != nil {
-			util.Logger.Errorf(err.Error())
-			panic(err)
+			if waitCtx.Err() != context.DeadlineExceeded {
+				util.Logger.Errorf(err.Error())
+				panic(err)
+			}
+			util.Logger.Errorf("execution timeout, task_id=%d", executionTask.TaskId)
+			timedOut = true
 		}
 	case <-statusCh:
 	}
+	run.receipt.endTime = time.Now().Unix()
 
 	out, err := cli.ContainerLogs(ctx, resp.ID, dockerTypes.ContainerLogsOptions{ShowStdout: true})
 	if err != nil {
 		panic(err)
 	}
-	f, err := os.Create(outputDir + "/log.txt")
-	io.Copy(f, out)
-	executeReport, err := readExecuteReport("./output/report.json")
+	f, err := os.Create(filepath.Join(run.outputDir, "log.txt"))
 	if err != nil {
-		util.Logger.Errorf(err.Error())
-		ex.receipt.returnCode = err.Error()
+		panic(err)
+	}
+	io.Copy(f, out)
+	f.Close()
+	executeReport, reportErr := readExecuteReport(filepath.Join(run.outputDir, executionReportFileName))
+	run.receipt.status = executionResultStatus(executeReport, reportErr, timedOut)
+	run.receipt.returnCode = executeReport.ResultMsg
+	run.receipt.gasUsed = executeReport.GasUsed
+	if timedOut {
+		run.receipt.returnCode = "execution timeout"
+		containerFailureCounter.WithLabelValues(containerFailureTimeout).Inc()
+	} else if reportErr != nil {
+		util.Logger.Errorf(reportErr.Error())
+		run.receipt.returnCode = reportErr.Error()
+		containerFailureCounter.WithLabelValues(containerFailureNoReport).Inc()
 	}
-	ex.receipt.returnCode = executeReport.ResultMsg
-	ex.receipt.gasUsed = executeReport.GasUsed
 	// 4. stop and destroy container
 	stopAndRemoveContainer(ctx, cli, resp.ID)
+	ranDetail := ""
+	if run.receipt.status != model.ExecutionResultStatusSuccess {
+		ranDetail = run.receipt.returnCode
+	}
+	ex.addTaskEvent(executionTask.TaskId, model.TaskEventRan, ranDetail)
+	phaseStart = observePhase(phaseExecution, phaseStart)
 
-	// 5. upload result data and logs
-	err = ex.uploadResultsAndLogs()
+	// 5. upload result data, logs and the attestation
+	err = ex.uploadResultsAndLogs(run)
+	if err != nil {
+		return
+	}
+	err = ex.uploadAttestation(run)
 	if err != nil {
 		return
 	}
 	// 6. write receipt into db
-	err = ex.writeReceipt()
+	err = ex.writeReceipt(run)
 	if err != nil {
 		return
 	}
+	executed = true
+	observePhase(phaseUpload, phaseStart)
+	taskCounter.WithLabelValues(run.receipt.status.String()).Inc()
+	gasUsedHistogram.Observe(float64(run.receipt.gasUsed))
 
 	return
 }
 
+// addTaskEvent records the progress of the task being executed, the failure to record it does not stop the execution
+// failTask records the failed attempt of the task, it is claimed again after the lease expires, or dead-lettered and
+// alerted once it runs out of attempts
+func (ex *Executor) failTask(task *model.ExecutionTask, err error) {
+	detail := ""
+	if err != nil {
+		detail = err.Error()
+	}
+	ex.addTaskEvent(task.TaskId, model.TaskEventFailed, detail)
+	taskCounter.WithLabelValues(outcomeFailed).Inc()
+	if task.ExecutionAttempts < ex.Scheduler.config.GetMaxExecutionAttempts() {
+		return
+	}
+
+	msg := fmt.Sprintf("[%s] execute task failed, dead-lettered, task_id=%d, attempts=%d, reason=%s",
+		ex.Config.AlertConfig.Moniker, task.TaskId, task.ExecutionAttempts, detail)
+	util.Logger.Error(msg)
+	util.SendSlackMessage(msg)
+	taskCounter.WithLabelValues(outcomeDeadLetter).Inc()
+	if err := ex.Store.MarkDeadLettered(task.TaskId, ex.Scheduler.worker, detail); err != nil {
+		util.Logger.Errorf("dead-letter task error, task_id=%d, err=%s", task.TaskId, err.Error())
+	}
+}
+
+func (ex *Executor) addTaskEvent(taskId int64, eventType model.TaskEventType, detail string) {
+	ex.runs.progress(taskId)
+	err := ex.Store.AddTaskEvent(&model.TaskEvent{
+		TaskId: taskId,
+		Event:  eventType,
+		Status: model.ExecutionTaskStatusStatusInit,
+		Worker: ex.Scheduler.worker,
+		Error:  detail,
+	})
+	if err != nil {
+		util.Logger.Errorf("add task event error, task_id=%d, event=%s, err=%s", taskId, eventType, err.Error())
+	}
+}
+
 func stopAndRemoveContainer(ctx context.Context, cli *client.Client, id string) {
 	util.Logger.Infof("stop and destroy container: " + id)
 	if err := cli.ContainerStop(ctx, id, nil); err != nil {
@@ -334,45 +500,49 @@ func readExecuteReport(reportJson string) (ExecutionReport, error) {
 	return report, err
 }
 
-func (ex *Executor) downloadObject(objectId string) (string, error) {
-	// create download dir
-	_ = os.Mkdir(downloadDir, os.ModePerm)
-
-	objectInfo, err := ex.Client.HeadObjectByID(context.Background(), objectId)
+// downloadObject downloads the object to the download dir of the run, and returns its path and bucket
+func (ex *Executor) downloadObject(run *execution, objectId string) (string, string, error) {
+	cli := ex.Clients.Client()
+	objectInfo, err := cli.HeadObjectByID(context.Background(), objectId)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	// remember the executable object bucket name for result upload
-	outputBucketName = objectInfo.BucketName
-
-	ior, _, err := ex.Client.GetObject(context.Background(), objectInfo.BucketName, objectInfo.ObjectName, types.GetObjectOption{})
+	ior, _, err := cli.GetObject(context.Background(), objectInfo.BucketName, objectInfo.ObjectName, types.GetObjectOption{})
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	bts, err := io.ReadAll(ior)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	objectPath := fmt.Sprintf("./%s/%s", downloadDir, objectInfo.ObjectName)
+	objectPath := filepath.Join(run.dir, downloadDir, objectInfo.ObjectName)
 	err = os.WriteFile(objectPath, bts, 0644)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	return objectPath, err
+	return objectPath, objectInfo.BucketName, err
 }
 
-func (ex *Executor) downloadExecutable(objectId string) (ExecutableConfig, string, error) {
+func (ex *Executor) downloadExecutable(run *execution) (ExecutableConfig, string, error) {
+	objectId := run.task.ExecutionObjectId
 	util.Logger.Infof("try to download executable, objectId=%s", objectId)
-	executableZip, err := ex.downloadObject(objectId)
+	executableZip, bucket, err := ex.downloadObject(run, objectId)
 	if err != nil {
 		util.Logger.Errorf("download executable failed, err=%s", err.Error())
 		return ExecutableConfig{}, "", err
 	}
-	unzipFile(executableZip, "./")
+	// the results are uploaded to the bucket of the executable
+	run.bucket = bucket
+	executableHash, err := attestation.HashFile(executableZip)
+	if err != nil {
+		return ExecutableConfig{}, "", err
+	}
+	run.receipt.executable = attestation.Object{ObjectId: objectId, Hash: executableHash}
+	unzipFile(executableZip, run.dir)
 
-	configDir, err := findDirectoryWithFile("./", executableConfigFileName)
+	configDir, err := findDirectoryWithFile(run.dir, executableConfigFileName)
 	if err != nil {
 		util.Logger.Errorf("can not find executable config file, err=%s", err.Error())
 		return ExecutableConfig{}, "", err
@@ -438,7 +608,8 @@ func readExecutableConfig(path string) (ExecutableConfig, error) {
 	return config, err
 }
 
-func (ex *Executor) downloadInputFiles(objectIds string, config ExecutableConfig) error {
+func (ex *Executor) downloadInputFiles(run *execution, config ExecutableConfig) error {
+	objectIds := run.task.InputFiles
 	util.Logger.Infof("try to download inputs, objects=%s", objectIds)
 	inputObjects := make([]string, 0)
 	err := json.Unmarshal([]byte(objectIds), &inputObjects)
@@ -447,14 +618,19 @@ func (ex *Executor) downloadInputFiles(objectIds string, config ExecutableConfig
 	}
 
 	for _, objectId := range inputObjects {
-		inputPath, err := ex.downloadObject(objectId)
+		inputPath, _, err := ex.downloadObject(run, objectId)
+		if err != nil {
+			return err
+		}
+		inputHash, err := attestation.HashFile(inputPath)
 		if err != nil {
 			return err
 		}
+		run.receipt.inputs = append(run.receipt.inputs, attestation.Object{ObjectId: objectId, Hash: inputHash})
 		if strings.HasSuffix(inputPath, ".zip") {
-			unzipFile(inputPath, ".")
+			unzipFile(inputPath, run.dir)
 			// check InputDir
-			_, err = findDirectoryWithFile(".", config.Data.InputDir)
+			_, err = findDirectoryWithFile(run.dir, config.Data.InputDir)
 			if err != nil {
 				util.Logger.Errorf("Can not find inputDir err=%s\n", err.Error())
 				return err
@@ -464,22 +640,23 @@ func (ex *Executor) downloadInputFiles(objectIds string, config ExecutableConfig
 	return nil
 }
 
-func (ex *Executor) uploadFile(dir string, fileName string) (string, error) {
+// uploadFile uploads the file to the bucket of the run as the object name, and returns the object id
+func (ex *Executor) uploadFile(run *execution, filePath string, objectName string) (string, error) {
 	// read file
-	filePath := dir + "/" + fileName
 	dataBuf, err := os.ReadFile(filePath)
 	if err != nil {
 		util.Logger.Error("Can not read input file: " + filePath)
 		return "", err
 	}
 
-	util.Logger.Infof("---> CreateObject (%s) and HeadObject into bucket (%s) <---\n", fileName, outputBucketName)
-	uploadTx, err := ex.Client.CreateObject(context.Background(), outputBucketName, fileName, bytes.NewReader(dataBuf), types.CreateObjectOptions{})
+	cli := ex.Clients.Client()
+	util.Logger.Infof("---> CreateObject (%s) and HeadObject into bucket (%s) <---\n", objectName, run.bucket)
+	uploadTx, err := cli.CreateObject(context.Background(), run.bucket, objectName, bytes.NewReader(dataBuf), types.CreateObjectOptions{})
 	if err != nil {
 		util.Logger.Error("Error create object: " + err.Error())
 		return "", err
 	}
-	_, err = ex.Client.WaitForTx(context.Background(), uploadTx)
+	_, err = cli.WaitForTx(context.Background(), uploadTx)
 	if err != nil {
 		util.Logger.Error("Error wait TX: " + err.Error())
 		return "", err
@@ -487,15 +664,15 @@ func (ex *Executor) uploadFile(dir string, fileName string) (string, error) {
 
 	time.Sleep(5 * time.Second)
 
-	util.Logger.Infof("---> PutObject (%s) <---\n", fileName)
-	err = ex.Client.PutObject(context.Background(), outputBucketName, fileName, int64(len(dataBuf)),
+	util.Logger.Infof("---> PutObject (%s) <---\n", objectName)
+	err = cli.PutObject(context.Background(), run.bucket, objectName, int64(len(dataBuf)),
 		bytes.NewReader(dataBuf), types.PutObjectOptions{})
 	if err != nil {
 		util.Logger.Error("Error put object: " + err.Error())
 		return "", err
 	}
 	time.Sleep(10 * time.Second)
-	dataObjectInfo, err := ex.Client.HeadObject(context.Background(), outputBucketName, fileName)
+	dataObjectInfo, err := cli.HeadObject(context.Background(), run.bucket, objectName)
 	if err != nil {
 		util.Logger.Error("Error HeadObject: " + err.Error())
 		return "", err
@@ -504,29 +681,76 @@ func (ex *Executor) uploadFile(dir string, fileName string) (string, error) {
 	return dataObjectInfo.Id.String(), nil
 }
 
-func (ex *Executor) uploadResultsAndLogs() error {
+// uploadResultsAndLogs uploads the result and log of the run, the object names are of the task so the runs in the
+// same bucket do not collide
+func (ex *Executor) uploadResultsAndLogs(run *execution) error {
+	resultObjectId, err := ex.uploadFile(run, filepath.Join(run.outputDir, "result.txt"), fmt.Sprintf("result_%d.txt", run.task.TaskId))
+	if err != nil {
+		return err
+	}
+	logObjectId, err := ex.uploadFile(run, filepath.Join(run.outputDir, "log.txt"), fmt.Sprintf("log_%d.txt", run.task.TaskId))
+
+	run.receipt.resultObjectId = resultObjectId
+	run.receipt.logObjectId = logObjectId
+	return err
+}
+
+// uploadAttestation signs the attestation of the run by the provider key and uploads it
+func (ex *Executor) uploadAttestation(run *execution) error {
+	taskId := run.task.TaskId
+	doc := attestation.Document{
+		TaskId:             taskId,
+		Executable:         run.receipt.executable,
+		Inputs:             run.receipt.inputs,
+		GasUsed:            run.receipt.gasUsed,
+		ResultStatus:       uint32(run.receipt.status),
+		RuntimeImage:       runtimeImage,
+		RuntimeImageDigest: run.receipt.imageDigest,
+		StartTime:          run.receipt.startTime,
+		EndTime:            run.receipt.endTime,
+	}
+	for _, fileName := range []string{"result.txt", "log.txt"} {
+		hash, err := attestation.HashFile(filepath.Join(run.outputDir, fileName))
+		if err != nil {
+			return err
+		}
+		doc.Outputs = append(doc.Outputs, attestation.Object{Name: fileName, Hash: hash})
+	}
+	doc.Outputs[0].ObjectId = run.receipt.resultObjectId
+	doc.Outputs[1].ObjectId = run.receipt.logObjectId
 
-	resultObjectId, err := ex.uploadFile(outputDir, "result.txt")
+	account, err := ex.Clients.Client().GetDefaultAccount()
+	if err != nil {
+		return err
+	}
+	signed, err := attestation.Sign(doc, account)
+	if err != nil {
+		return err
+	}
+	bz, err := json.Marshal(signed)
 	if err != nil {
 		return err
 	}
-	logObjectId, err := ex.uploadFile(outputDir, "log.txt")
 
-	ex.receipt.resultObjectId = resultObjectId
-	ex.receipt.logObjectId = logObjectId
+	fileName := fmt.Sprintf("attestation_%d.json", taskId)
+	filePath := filepath.Join(run.outputDir, fileName)
+	if err := os.WriteFile(filePath, bz, 0644); err != nil {
+		return err
+	}
+	run.receipt.attestationObjectId, err = ex.uploadFile(run, filePath, fileName)
 	return err
 }
 
-func (ex *Executor) writeReceipt() error {
-	err := ex.DB.Model(&model.ExecutionTask{}).Where("status = ? and task_id = ?", model.ExecutionTaskStatusStatusInit,
-		ex.currentTaskId).Updates(
-		map[string]interface{}{
-			"status":           model.ExecutionTaskStatusStatusExecuted,
-			"gas_used":         ex.receipt.gasUsed,
-			"execution_status": ex.receipt.returnCode,
-			"result_data_uri":  ex.receipt.resultObjectId,
-			"log_data_uri":     ex.receipt.logObjectId,
-		}).Error
+func (ex *Executor) writeReceipt(run *execution) error {
+	err := ex.Store.MarkExecuted(run.task.TaskId, store.ExecutionReceipt{
+		Worker:          ex.Scheduler.worker,
+		GasUsed:         int64(run.receipt.gasUsed),
+		ResultStatus:    run.receipt.status,
+		ExecutionStatus: run.receipt.returnCode,
+		ResultDataUri:   run.receipt.resultObjectId,
+		LogDataUri:      run.receipt.logObjectId,
+		AttestationUri:  run.receipt.attestationObjectId,
+	})
 
 	if err != nil {
 		util.Logger.Error("Fail to update executed task")
@@ -0,0 +1,81 @@
+package executor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+func TestExecutionResultStatus(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		report    ExecutionReport
+		reportErr error
+		timedOut  bool
+		status    model.ExecutionResultStatus
+	}{
+		{"success", ExecutionReport{GasUsed: 100, ResultMsg: "Success"}, nil, false, model.ExecutionResultStatusSuccess},
+		{"trap", ExecutionReport{GasUsed: 100, ResultMsg: "Exception: unreachable"}, nil, false, model.ExecutionResultStatusTrap},
+		{"out of gas", ExecutionReport{GasUsed: 1000, ResultMsg: "Exception: GreenfieldVM: OutOfGas, need 10 but has 5 left."}, nil, false, model.ExecutionResultStatusOutOfGas},
+		{"timeout", ExecutionReport{ResultMsg: "nil"}, errors.New("no report"), true, model.ExecutionResultStatusTimeout},
+		{"provider failure", ExecutionReport{ResultMsg: "nil"}, errors.New("open ./output/report.json: no such file or directory"), false, model.ExecutionResultStatusProviderFailure},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.status, executionResultStatus(c.report, c.reportErr, c.timedOut))
+		})
+	}
+}
+
+func TestNewExecution(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	// the runs of different tasks do not share files
+	run1, err := newExecution(&model.ExecutionTask{TaskId: 1})
+	require.NoError(t, err)
+	run2, err := newExecution(&model.ExecutionTask{TaskId: 2})
+	require.NoError(t, err)
+	require.NotEqual(t, run1.dir, run2.dir)
+	require.True(t, filepath.IsAbs(run1.dir))
+	require.DirExists(t, filepath.Join(run1.dir, downloadDir))
+
+	// the files left by a previous run of the task are dropped
+	stale := filepath.Join(run1.dir, "output", "report.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(stale), os.ModePerm))
+	require.NoError(t, os.WriteFile(stale, []byte("{}"), 0644))
+	run1, err = newExecution(&model.ExecutionTask{TaskId: 1})
+	require.NoError(t, err)
+	require.NoFileExists(t, stale)
+	require.Equal(t, Receipt{}, run1.receipt)
+}
+
+func TestFailTask(t *testing.T) {
+	st := store.NewMemoryStore()
+	ex := NewExecutor(st, &util.ExecutorConfig{AlertConfig: &util.AlertConfig{Moniker: "test"},
+		SchedulerConfig: &util.SchedulerConfig{MaxExecutionAttempts: 2}}, nil)
+	require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: 1, Status: model.ExecutionTaskStatusStatusInit}))
+
+	// the failed task is claimed again until it runs out of attempts
+	for attempts := 1; attempts <= 2; attempts++ {
+		task, err := st.ClaimNextTask([]int64{1}, "test", time.Now().Unix()-1)
+		require.NoError(t, err)
+		require.Equal(t, attempts, task.ExecutionAttempts)
+		ex.failTask(task, errors.New("download executable error"))
+	}
+	task, err := st.GetTask(1)
+	require.NoError(t, err)
+	require.Equal(t, model.ExecutionTaskStatusStatusDeadLetter, task.Status)
+	require.Equal(t, "download executable error", task.ExecutionStatus)
+	_, err = st.ClaimNextTask([]int64{1}, "test", time.Now().Unix()-1)
+	require.Equal(t, store.ErrNotFound, err)
+}
@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// runTracker keeps the time each running task last made progress, i.e. it was claimed or finished a phase
+type runTracker struct {
+	mtx          sync.Mutex
+	lastProgress map[int64]time.Time
+}
+
+func newRunTracker() *runTracker {
+	return &runTracker{lastProgress: make(map[int64]time.Time)}
+}
+
+func (r *runTracker) start(taskId int64) {
+	r.progress(taskId)
+}
+
+func (r *runTracker) progress(taskId int64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.lastProgress[taskId] = time.Now()
+}
+
+func (r *runTracker) done(taskId int64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	delete(r.lastProgress, taskId)
+}
+
+// check returns an error if a task made no progress within the timeout, e.g. the image pull or an upload hangs
+func (r *runTracker) check(timeout time.Duration) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	var stuck []int64
+	for taskId, last := range r.lastProgress {
+		if time.Since(last) > timeout {
+			stuck = append(stuck, taskId)
+		}
+	}
+	if len(stuck) == 0 {
+		return nil
+	}
+	sort.Slice(stuck, func(i, j int) bool { return stuck[i] < stuck[j] })
+	return fmt.Errorf("tasks made no progress within %s, task_ids=%v", timeout, stuck)
+}
+
+// LivenessChecks returns the heartbeat of the scheduler and the progress of the running tasks, a task running a
+// phase longer than its lease is considered stuck
+func (ex *Executor) LivenessChecks() map[string]util.HealthCheck {
+	return map[string]util.HealthCheck{
+		"schedule": ex.scheduleHeartbeat.Check,
+		"progress": func() error {
+			return ex.runs.check(common.ExecutorLeaseDuration)
+		},
+	}
+}
+
+// ReadinessChecks returns whether the rpc endpoints and the docker daemon running the executables are reachable
+func (ex *Executor) ReadinessChecks() map[string]util.HealthCheck {
+	checks := map[string]util.HealthCheck{
+		"docker": pingDocker,
+	}
+	if ex.Clients != nil {
+		checks["rpc"] = ex.Clients.Reachable
+	}
+	return checks
+}
+
+func pingDocker() error {
+	ctx, cancel := context.WithTimeout(context.Background(), common.HealthCheckTimeout)
+	defer cancel()
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	_, err = cli.Ping(ctx)
+	return err
+}
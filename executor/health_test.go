@@ -0,0 +1,23 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTracker(t *testing.T) {
+	runs := newRunTracker()
+	runs.start(1)
+	runs.start(2)
+	require.NoError(t, runs.check(50*time.Millisecond))
+
+	// task 2 finishes a phase in time, task 1 is stuck
+	time.Sleep(100 * time.Millisecond)
+	runs.progress(2)
+	require.EqualError(t, runs.check(50*time.Millisecond), "tasks made no progress within 50ms, task_ids=[1]")
+
+	runs.done(1)
+	require.NoError(t, runs.check(50*time.Millisecond))
+}
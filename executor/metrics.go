@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+)
+
+var (
+	queueDepthGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "executor",
+		Name:      "queue_depth",
+		Help:      "The pending tasks of each operator.",
+	}, []string{"operator"})
+	runningGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "executor",
+		Name:      "running_tasks",
+		Help:      "The tasks of each operator being executed by this executor.",
+	}, []string{"operator"})
+	taskCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "executor",
+		Name:      "tasks_total",
+		Help:      "The tasks executed by the result status, or failed to be executed, or dead-lettered after all attempts.",
+	}, []string{"outcome"})
+	phaseHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "executor",
+		Name:      "phase_duration_seconds",
+		Help:      "The time of downloading, running and uploading the tasks.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 14),
+	}, []string{"phase"})
+	gasUsedHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "executor",
+		Name:      "gas_used",
+		Help:      "The gas used by the tasks executed.",
+		Buckets:   prometheus.ExponentialBuckets(1000, 4, 12),
+	})
+	containerFailureCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "executor",
+		Name:      "container_failures_total",
+		Help:      "The runtime containers timed out or finished without a report.",
+	}, []string{"reason"})
+)
+
+const (
+	outcomeFailed     = "failed"
+	outcomeDeadLetter = "dead_letter"
+
+	phaseDownload  = "download"
+	phaseExecution = "execution"
+	phaseUpload    = "upload"
+
+	containerFailureTimeout  = "timeout"
+	containerFailureNoReport = "no_report"
+)
+
+// observePhase observes the phase started at the time and returns the end of it
+func observePhase(phase string, start time.Time) time.Time {
+	end := time.Now()
+	phaseHistogram.WithLabelValues(phase).Observe(end.Sub(start).Seconds())
+	return end
+}
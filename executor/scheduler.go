@@ -0,0 +1,307 @@
+package executor
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+type gasUsage struct {
+	time int64
+	gas  uint64
+}
+
+// Scheduler picks the next task to execute. operators exceeding their quotas are skipped, and among
+// the others the task of the highest priority goes first. with the fifo policy all tasks are of the
+// same priority, then the operator with the fewest tasks started in the window goes first, so one
+// operator invoking lots of tasks can not starve the others.
+type Scheduler struct {
+	mtx    sync.Mutex
+	config *util.SchedulerConfig
+	worker string // the executor the tasks picked are leased to
+
+	picked    map[int64]bool     // tasks picked by this process, they are not picked again
+	running   map[string]int     // operator -> number of running tasks
+	starts    map[string][]int64 // operator -> start times of tasks in the window
+	gasUsages map[string][]gasUsage
+}
+
+func NewScheduler(cfg *util.SchedulerConfig, worker string) *Scheduler {
+	if cfg == nil {
+		cfg = &util.SchedulerConfig{}
+	}
+	return &Scheduler{
+		config:    cfg,
+		worker:    worker,
+		picked:    make(map[int64]bool),
+		running:   make(map[string]int),
+		starts:    make(map[string][]int64),
+		gasUsages: make(map[string][]gasUsage),
+	}
+}
+
+// QueueDepths returns the pending tasks of each operator, the tasks picked already are excluded
+func (s *Scheduler) QueueDepths(st store.Store) ([]store.OperatorQueue, error) {
+	return st.QueueDepths(s.pickedIds())
+}
+
+// candidate is the task an operator would execute next
+type candidate struct {
+	TaskId   int64
+	Operator string
+	Priority uint64
+}
+
+// Next picks the next task to execute, it returns store.ErrNotFound if there is no task to execute.
+// tasks of higher priority go first, and among tasks of the same priority the operator with the fewest
+// tasks started in the window goes first.
+func (s *Scheduler) Next(st store.Store) (*model.ExecutionTask, error) {
+	if s.totalRunning() >= s.config.GetMaxRunningTasks() {
+		return nil, store.ErrNotFound
+	}
+
+	var candidates []candidate
+	var err error
+	if s.config.GetPriorityPolicy() == common.PriorityPolicyMaxGas {
+		candidates, err = s.candidatesByMaxGas(st)
+	} else {
+		candidates, err = s.candidatesByQueue(st)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now().Unix()
+	s.expire(now)
+	// the tasks may have started since the check above
+	if s.totalRunningLocked() >= s.config.GetMaxRunningTasks() {
+		return nil, store.ErrNotFound
+	}
+
+	var allowed []candidate
+	for _, c := range candidates {
+		if s.withinQuota(c.Operator) {
+			allowed = append(allowed, c)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, store.ErrNotFound
+	}
+
+	sort.Slice(allowed, func(i, j int) bool {
+		if allowed[i].Priority != allowed[j].Priority {
+			return allowed[i].Priority > allowed[j].Priority
+		}
+		startsI, startsJ := len(s.starts[allowed[i].Operator]), len(s.starts[allowed[j].Operator])
+		if startsI != startsJ {
+			return startsI < startsJ
+		}
+		return allowed[i].TaskId < allowed[j].TaskId
+	})
+
+	// the tasks may be claimed by other executors in the meantime, the first one still pending is leased
+	taskIds := make([]int64, 0, len(allowed))
+	for _, c := range allowed {
+		taskIds = append(taskIds, c.TaskId)
+	}
+	task, err := st.ClaimNextTask(taskIds, s.worker, time.Now().Add(common.ExecutorLeaseDuration).Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	s.picked[task.TaskId] = true
+	s.running[task.Operator]++
+	s.starts[task.Operator] = append(s.starts[task.Operator], now)
+	return task, nil
+}
+
+// candidatesByQueue returns the first pending task of each operator, all of the same priority
+func (s *Scheduler) candidatesByQueue(st store.Store) ([]candidate, error) {
+	queues, err := s.QueueDepths(st)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]candidate, 0, len(queues))
+	for _, queue := range queues {
+		candidates = append(candidates, candidate{TaskId: queue.NextTaskId, Operator: queue.Operator})
+	}
+	return candidates, nil
+}
+
+// candidatesByMaxGas returns all pending tasks with their max gas plus the aging boost as the priority
+func (s *Scheduler) candidatesByMaxGas(st store.Store) ([]candidate, error) {
+	tasks, err := st.PendingTasks(s.pickedIds())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	candidates := make([]candidate, 0, len(tasks))
+	for _, task := range tasks {
+		candidates = append(candidates, candidate{
+			TaskId:   task.TaskId,
+			Operator: task.Operator,
+			Priority: s.priority(task.MaxGas, now-task.CreateTime),
+		})
+	}
+	return candidates, nil
+}
+
+// priority returns the max gas of the task raised by the time it has waited, saturating at math.MaxUint64
+func (s *Scheduler) priority(maxGas string, waited int64) uint64 {
+	priority, err := strconv.ParseUint(maxGas, 10, 64)
+	if err != nil {
+		// max gas is an u256 on chain, the ones not fitting in uint64 are of the highest priority
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			return math.MaxUint64
+		}
+		return 0
+	}
+
+	if s.config.AgingInterval <= 0 || s.config.AgingBoost == 0 || waited <= 0 {
+		return priority
+	}
+	intervals := uint64(waited / s.config.AgingInterval)
+	if intervals > 0 && s.config.AgingBoost > (math.MaxUint64-priority)/intervals {
+		return math.MaxUint64
+	}
+	return priority + intervals*s.config.AgingBoost
+}
+
+// pickedIds returns the ids of the tasks picked by this process
+func (s *Scheduler) pickedIds() []int64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	pickedIds := make([]int64, 0, len(s.picked))
+	for taskId := range s.picked {
+		pickedIds = append(pickedIds, taskId)
+	}
+	return pickedIds
+}
+
+// Done records the task finished with the gas used by its run. a task not executed is picked again after its
+// lease expires
+func (s *Scheduler) Done(task *model.ExecutionTask, executed bool, gasUsed uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.running[task.Operator] > 0 {
+		s.running[task.Operator]--
+	}
+	delete(s.picked, task.TaskId)
+	if executed {
+		s.gasUsages[task.Operator] = append(s.gasUsages[task.Operator], gasUsage{time: time.Now().Unix(), gas: gasUsed})
+	}
+}
+
+// withinQuota returns whether the operator can start another task
+func (s *Scheduler) withinQuota(operator string) bool {
+	if s.config.MaxConcurrentTasks > 0 && s.running[operator] >= s.config.MaxConcurrentTasks {
+		return false
+	}
+	if s.config.MaxTasksPerWindow > 0 && len(s.starts[operator]) >= s.config.MaxTasksPerWindow {
+		return false
+	}
+	if s.config.MaxGasPerWindow > 0 {
+		var gas uint64
+		for _, usage := range s.gasUsages[operator] {
+			gas += usage.gas
+		}
+		if gas >= s.config.MaxGasPerWindow {
+			return false
+		}
+	}
+	return true
+}
+
+// expire drops the records out of the window
+func (s *Scheduler) expire(now int64) {
+	windowStart := now - s.config.GetWindow()
+	for operator, starts := range s.starts {
+		idx := sort.Search(len(starts), func(i int) bool { return starts[i] > windowStart })
+		if idx == len(starts) {
+			delete(s.starts, operator)
+		} else {
+			s.starts[operator] = starts[idx:]
+		}
+	}
+	for operator, usages := range s.gasUsages {
+		idx := sort.Search(len(usages), func(i int) bool { return usages[i].time > windowStart })
+		if idx == len(usages) {
+			delete(s.gasUsages, operator)
+		} else {
+			s.gasUsages[operator] = usages[idx:]
+		}
+	}
+}
+
+// ReportQueueDepths logs and reports the queue depth of each operator periodically
+func (s *Scheduler) ReportQueueDepths(st store.Store) {
+	for {
+		time.Sleep(common.ExecutorQueueReportInterval)
+
+		queues, err := s.QueueDepths(st)
+		if err != nil {
+			util.Logger.Errorf("get queue depths error, err=%s", err.Error())
+			continue
+		}
+		// the operators without pending or running tasks are not reported
+		queueDepthGauge.Reset()
+		for _, queue := range queues {
+			util.Logger.Infof("task queue, operator=%s, depth=%d, running=%d", queue.Operator, queue.Depth, s.Running(queue.Operator))
+			queueDepthGauge.WithLabelValues(queue.Operator).Set(float64(queue.Depth))
+		}
+		runningGauge.Reset()
+		for operator, running := range s.runningTasks() {
+			runningGauge.WithLabelValues(operator).Set(float64(running))
+		}
+	}
+}
+
+// Running returns the number of running tasks of the operator
+func (s *Scheduler) Running(operator string) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.running[operator]
+}
+
+// totalRunning returns the number of running tasks of all operators
+func (s *Scheduler) totalRunning() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.totalRunningLocked()
+}
+
+func (s *Scheduler) totalRunningLocked() int {
+	var total int
+	for _, count := range s.running {
+		total += count
+	}
+	return total
+}
+
+// runningTasks returns the number of running tasks of each operator with any
+func (s *Scheduler) runningTasks() map[string]int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	running := make(map[string]int, len(s.running))
+	for operator, count := range s.running {
+		if count > 0 {
+			running[operator] = count
+		}
+	}
+	return running
+}
@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// newTestStore returns a store with the tasks of operators, operators maps task ids to operators
+func newTestStore(t *testing.T, operators map[int64]string) store.Store {
+	st := store.NewMemoryStore()
+	for taskId, operator := range operators {
+		require.NoError(t, st.CreateTask(&model.ExecutionTask{
+			TaskId:   taskId,
+			Operator: operator,
+			MaxGas:   "1000",
+			Status:   model.ExecutionTaskStatusStatusInit,
+		}))
+	}
+	return st
+}
+
+func nextTaskIds(t *testing.T, st store.Store, scheduler *Scheduler, count int) []int64 {
+	var taskIds []int64
+	for i := 0; i < count; i++ {
+		task, err := scheduler.Next(st)
+		if err == store.ErrNotFound {
+			break
+		}
+		require.NoError(t, err)
+		taskIds = append(taskIds, task.TaskId)
+	}
+	return taskIds
+}
+
+func TestSchedulerFairShare(t *testing.T) {
+	st := newTestStore(t, map[int64]string{1: "a", 2: "a", 3: "a", 4: "a", 5: "b", 6: "b"})
+	scheduler := NewScheduler(&util.SchedulerConfig{MaxRunningTasks: 10}, "test")
+
+	queues, err := scheduler.QueueDepths(st)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []store.OperatorQueue{
+		{Operator: "a", Depth: 4, NextTaskId: 1},
+		{Operator: "b", Depth: 2, NextTaskId: 5},
+	}, queues)
+
+	require.Equal(t, []int64{1, 5, 2, 6, 3, 4}, nextTaskIds(t, st, scheduler, 10))
+}
+
+func TestSchedulerQuotas(t *testing.T) {
+	st := newTestStore(t, map[int64]string{1: "a", 2: "a", 3: "a", 4: "b"})
+	scheduler := NewScheduler(&util.SchedulerConfig{MaxConcurrentTasks: 1}, "test")
+
+	require.Equal(t, []int64{1, 4}, nextTaskIds(t, st, scheduler, 10))
+
+	// a failed task is not picked again until its lease expires
+	scheduler.Done(&model.ExecutionTask{TaskId: 1, Operator: "a"}, false, 0)
+	require.Equal(t, []int64{2}, nextTaskIds(t, st, scheduler, 10))
+	require.NotContains(t, scheduler.pickedIds(), int64(1))
+
+	st = newTestStore(t, map[int64]string{1: "a", 2: "b", 3: "c", 4: "d"})
+	scheduler = NewScheduler(&util.SchedulerConfig{MaxRunningTasks: 2}, "test")
+	require.Equal(t, []int64{1, 2}, nextTaskIds(t, st, scheduler, 10))
+	scheduler.Done(&model.ExecutionTask{TaskId: 1, Operator: "a"}, true, 0)
+	require.Equal(t, []int64{3}, nextTaskIds(t, st, scheduler, 10))
+
+	st = newTestStore(t, map[int64]string{1: "a", 2: "a", 3: "a", 4: "b"})
+	scheduler = NewScheduler(&util.SchedulerConfig{MaxTasksPerWindow: 2}, "test")
+	require.Equal(t, []int64{1, 4, 2}, nextTaskIds(t, st, scheduler, 10))
+
+	st = newTestStore(t, map[int64]string{1: "a", 2: "a", 3: "b"})
+	scheduler = NewScheduler(&util.SchedulerConfig{MaxGasPerWindow: 100}, "test")
+	task, err := scheduler.Next(st)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), task.TaskId)
+	require.NoError(t, st.MarkExecuted(task.TaskId, store.ExecutionReceipt{GasUsed: 100}))
+	scheduler.Done(task, true, 100)
+
+	require.Equal(t, []int64{3}, nextTaskIds(t, st, scheduler, 10))
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	// the creation times of tasks are set in the past on a sql store
+	db, err := gorm.Open(common.DBDialectSqlite3, filepath.Join(t.TempDir(), "executor.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, model.Migrate(db))
+
+	now := time.Now().Unix()
+	for _, task := range []struct {
+		taskId   int64
+		operator string
+		maxGas   string
+		waited   int64
+	}{
+		{1, "a", "100", 0},
+		{2, "a", "300", 0},
+		{3, "b", "200", 0},
+		{4, "b", "100", 250}, // raised to 100 + 2*100 by aging
+		{5, "c", "1000000000000000000000", 0},
+	} {
+		require.NoError(t, db.Create(&model.ExecutionTask{
+			TaskId:   task.taskId,
+			Operator: task.operator,
+			MaxGas:   task.maxGas,
+			Status:   model.ExecutionTaskStatusStatusInit,
+		}).Error)
+		require.NoError(t, db.Model(&model.ExecutionTask{}).Where("task_id = ?", task.taskId).
+			UpdateColumn("create_time", now-task.waited).Error)
+	}
+
+	scheduler := NewScheduler(&util.SchedulerConfig{
+		PriorityPolicy:  common.PriorityPolicyMaxGas,
+		AgingInterval:   100,
+		AgingBoost:      100,
+		MaxRunningTasks: 10,
+	}, "test")
+	require.Equal(t, []int64{5, 2, 4, 3, 1}, nextTaskIds(t, store.NewSQLStore(db), scheduler, 10))
+}
+
+func TestSchedulerRetry(t *testing.T) {
+	db, err := gorm.Open(common.DBDialectSqlite3, filepath.Join(t.TempDir(), "executor.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, model.Migrate(db))
+	st := store.NewSQLStore(db)
+	require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: 1, Operator: "a", MaxGas: "1000", Status: model.ExecutionTaskStatusStatusInit}))
+
+	scheduler := NewScheduler(nil, "test")
+	task, err := scheduler.Next(st)
+	require.NoError(t, err)
+	scheduler.Done(task, false, 0)
+	_, err = scheduler.Next(st)
+	require.Equal(t, store.ErrNotFound, err)
+
+	// the failed task is picked again once its lease expires
+	require.NoError(t, db.Model(&model.ExecutionTask{}).Where("task_id = ?", 1).Update("lease_expire_time", 0).Error)
+	task, err = scheduler.Next(st)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), task.TaskId)
+}
@@ -0,0 +1,306 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Migration is a versioned change of the schema. the steps check what exists before changing it, so the databases
+// created by former versions without schema_version are migrated forward, and a migration interrupted on the
+// dialects without transactional ddl, e.g. mysql, is completed by the next run
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *gorm.DB) error
+}
+
+// SchemaVersion is a migration applied to the database
+type SchemaVersion struct {
+	Version     int `gorm:"primary_key;auto_increment:false"`
+	Description string
+	AppliedTime int64
+}
+
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// Migrations are the migrations in order of version, a new one is appended for every change of the schema and the
+// applied ones are never changed
+var Migrations = []Migration{
+	{1, "create block_log, event_log and execution_task", createTables},
+	{2, "add index of event_log on status and height", func(tx *gorm.DB) error {
+		return addIndex(tx, EventLog{}.TableName(), "idx_event_log_status_height", false, "status", "height")
+	}},
+	{3, "add operator and reject_reason to execution_task", func(tx *gorm.DB) error {
+		err := addColumns(tx, ExecutionTask{}.TableName(),
+			column{"operator", stringColumn},
+			column{"reject_reason", stringColumn},
+		)
+		if err != nil {
+			return err
+		}
+		return addIndex(tx, ExecutionTask{}.TableName(), "idx_execution_task_status_operator", false, "status", "operator")
+	}},
+	{4, "add result_status and attestation_uri to execution_task", func(tx *gorm.DB) error {
+		return addColumns(tx, ExecutionTask{}.TableName(),
+			column{"result_status", intColumn},
+			column{"attestation_uri", stringColumn},
+		)
+	}},
+	{5, "add submission columns to execution_task", func(tx *gorm.DB) error {
+		return addColumns(tx, ExecutionTask{}.TableName(),
+			column{"submit_account", stringColumn},
+			column{"submit_tx_height", intColumn},
+			column{"submit_tx_code", intColumn},
+			column{"submit_attempts", intColumn},
+			column{"submit_time", intColumn},
+			column{"next_submit_time", intColumn},
+			column{"submit_error", stringColumn},
+			column{"result_submitter", stringColumn},
+		)
+	}},
+	{6, "add lease columns to execution_task", func(tx *gorm.DB) error {
+		return addColumns(tx, ExecutionTask{}.TableName(),
+			column{"lease_owner", stringColumn},
+			column{"lease_expire_time", intColumn},
+		)
+	}},
+	{7, "create task_event", func(tx *gorm.DB) error {
+		if !tx.HasTable(&taskEventV7{}) {
+			if err := tx.CreateTable(&taskEventV7{}).Error; err != nil {
+				return err
+			}
+		}
+		return addIndex(tx, TaskEvent{}.TableName(), "idx_task_event_task_id", false, "task_id")
+	}},
+	{8, "change the error columns of execution_task to text", func(tx *gorm.DB) error {
+		return changeToText(tx, ExecutionTask{}.TableName(), "execution_status", "submit_error", "reject_reason")
+	}},
+	{9, "add unique index of execution_task on task_id", func(tx *gorm.DB) error {
+		// the duplicates are left to the operator, deleting either may lose an executed result
+		var duplicates []int64
+		if err := tx.Model(&ExecutionTask{}).Group("task_id").Having("count(*) > 1").Pluck("task_id", &duplicates).Error; err != nil {
+			return err
+		}
+		if len(duplicates) > 0 {
+			return fmt.Errorf("duplicated task ids %v in execution_task", duplicates)
+		}
+		return addIndex(tx, ExecutionTask{}.TableName(), "idx_execution_task_task_id", true, "task_id")
+	}},
+	{10, "add execution_attempts to execution_task", func(tx *gorm.DB) error {
+		return addColumns(tx, ExecutionTask{}.TableName(), column{"execution_attempts", intColumn})
+	}},
+}
+
+// Migrate applies the migrations not applied yet in order, each one in a transaction along with its version
+func Migrate(db *gorm.DB) error {
+	if !db.HasTable(&SchemaVersion{}) {
+		if err := db.CreateTable(&SchemaVersion{}).Error; err != nil {
+			return fmt.Errorf("create schema_version error, err=%s", err.Error())
+		}
+	}
+
+	version, err := CurrentSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	for _, migration := range Migrations {
+		if migration.Version <= version {
+			continue
+		}
+
+		tx := db.Begin()
+		if err := tx.Error; err != nil {
+			return err
+		}
+		if err := migration.Up(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate schema to version %d error, err=%s", migration.Version, err.Error())
+		}
+		err := tx.Create(&SchemaVersion{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedTime: time.Now().Unix(),
+		}).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CurrentSchemaVersion returns the version of the last migration applied, 0 if none is applied
+func CurrentSchemaVersion(db *gorm.DB) (int, error) {
+	var versions []int
+	if err := db.Model(&SchemaVersion{}).Order("version desc").Limit(1).Pluck("version", &versions).Error; err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	return versions[0], nil
+}
+
+// the column types are portable across the dialects, the existing rows take the defaults
+const (
+	stringColumn = "varchar(255) NOT NULL DEFAULT ''"
+	intColumn    = "bigint NOT NULL DEFAULT 0"
+)
+
+type column struct {
+	name    string
+	sqlType string
+}
+
+// addColumns adds the columns missing in the table
+func addColumns(tx *gorm.DB, table string, columns ...column) error {
+	for _, c := range columns {
+		if tx.Dialect().HasColumn(table, c.name) {
+			continue
+		}
+		err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", tx.Dialect().Quote(table), tx.Dialect().Quote(c.name), c.sqlType)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changeToText changes the columns to text, so the long errors are not truncated or rejected. sqlite does not check
+// the length of varchar, the columns of it are kept
+func changeToText(tx *gorm.DB, table string, columns ...string) error {
+	sqlType := "text"
+	switch tx.Dialect().GetName() {
+	case "sqlite3":
+		return nil
+	case "mysql":
+		// mysql replaces the whole definition of the column, text takes no default before 8.0.13
+		sqlType = "text NOT NULL"
+	}
+	for _, name := range columns {
+		if err := tx.Table(table).ModifyColumn(name, sqlType).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addIndex adds the index if it is missing in the table
+func addIndex(tx *gorm.DB, table string, name string, unique bool, columns ...string) error {
+	if tx.Dialect().HasIndex(table, name) {
+		return nil
+	}
+	if unique {
+		return tx.Table(table).AddUniqueIndex(name, columns...).Error
+	}
+	return tx.Table(table).AddIndex(name, columns...).Error
+}
+
+// the tables as they were first released, the later columns are added by the following migrations
+
+type blockLogV1 struct {
+	Id         int64
+	Chain      string
+	BlockHash  string
+	ParentHash string
+	Height     int64
+	BlockTime  int64
+	CreateTime int64
+}
+
+func (blockLogV1) TableName() string {
+	return "block_log"
+}
+
+type eventLogV1 struct {
+	Id        int64
+	EventName string
+
+	TaskId             int64
+	Operator           string
+	ExecutableObjectId string
+	InputObjectIds     string
+	MaxGas             string
+	Method             string
+	Params             string
+
+	Status       int
+	BlockHash    string
+	TxHash       string
+	Height       int64
+	ConfirmedNum int64
+	CreateTime   int64
+	UpdateTime   int64
+}
+
+func (eventLogV1) TableName() string {
+	return "event_log"
+}
+
+type executionTaskV1 struct {
+	Id int64
+
+	InvokeTxHash string
+	TaskId       int64
+
+	ExecutionObjectId string
+	ExecutionUri      string
+
+	InputFiles   string
+	MaxGas       string
+	InvokeMethod string
+	Params       string
+
+	GasUsed         int64
+	ExecutionStatus string
+	ResultDataUri   string
+	LogDataUri      string
+	SubmitTxHash    string
+
+	Status     int
+	CreateTime int64
+	UpdateTime int64
+}
+
+func (executionTaskV1) TableName() string {
+	return "execution_task"
+}
+
+func createTables(tx *gorm.DB) error {
+	for _, table := range []interface{}{&blockLogV1{}, &eventLogV1{}, &executionTaskV1{}} {
+		if tx.HasTable(table) {
+			continue
+		}
+		if err := tx.CreateTable(table).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := addIndex(tx, BlockLog{}.TableName(), "idx_block_log_height", true, "height"); err != nil {
+		return err
+	}
+	return addIndex(tx, BlockLog{}.TableName(), "idx_block_log_create_time", false, "create_time")
+}
+
+// the tables created by the later migrations, as they were created
+
+type taskEventV7 struct {
+	Id        int64
+	TaskId    int64
+	Event     string
+	Status    int
+	Worker    string
+	Error     string `gorm:"type:text"`
+	EventTime int64
+}
+
+func (taskEventV7) TableName() string {
+	return "task_event"
+}
@@ -0,0 +1,153 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/require"
+)
+
+// testDialects runs the test on an empty database of each dialect, sqlite always, and mysql and postgres if
+// TEST_MYSQL_DSN and TEST_POSTGRES_DSN are set
+func testDialects(t *testing.T, test func(t *testing.T, db *gorm.DB)) {
+	t.Run("sqlite3", func(t *testing.T) {
+		db, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "model.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		test(t, db)
+	})
+
+	for dialect, env := range map[string]string{"mysql": "TEST_MYSQL_DSN", "postgres": "TEST_POSTGRES_DSN"} {
+		dialect, env := dialect, env
+		t.Run(dialect, func(t *testing.T) {
+			dsn := os.Getenv(env)
+			if dsn == "" {
+				t.Skipf("%s not set", env)
+			}
+			db, err := gorm.Open(dialect, dsn)
+			require.NoError(t, err)
+			dropTables := func() {
+				require.NoError(t, db.DropTableIfExists(&SchemaVersion{}, &BlockLog{}, &EventLog{}, &ExecutionTask{}, &TaskEvent{}).Error)
+			}
+			dropTables()
+			t.Cleanup(func() {
+				dropTables()
+				db.Close()
+			})
+			test(t, db)
+		})
+	}
+}
+
+// requireSchemaOfModels checks that every field of the models has its column
+func requireSchemaOfModels(t *testing.T, db *gorm.DB) {
+	for _, value := range []interface{}{&BlockLog{}, &EventLog{}, &ExecutionTask{}, &TaskEvent{}} {
+		scope := db.NewScope(value)
+		for _, field := range scope.GetModelStruct().StructFields {
+			require.True(t, db.Dialect().HasColumn(scope.TableName(), field.DBName), "column %s.%s missing", scope.TableName(), field.DBName)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	testDialects(t, func(t *testing.T, db *gorm.DB) {
+		require.NoError(t, Migrate(db))
+		version, err := CurrentSchemaVersion(db)
+		require.NoError(t, err)
+		require.Equal(t, Migrations[len(Migrations)-1].Version, version)
+		requireSchemaOfModels(t, db)
+
+		// migrating again changes nothing
+		require.NoError(t, Migrate(db))
+		var count int
+		require.NoError(t, db.Model(&SchemaVersion{}).Count(&count).Error)
+		require.Equal(t, len(Migrations), count)
+
+		task := ExecutionTask{TaskId: 1, SubmitError: "error", AttestationUri: "10"}
+		require.NoError(t, db.Create(&task).Error)
+		require.NoError(t, db.Where("task_id = ?", 1).Take(&task).Error)
+		require.Equal(t, "10", task.AttestationUri)
+		require.Error(t, db.Create(&ExecutionTask{TaskId: 1}).Error)
+
+		event := TaskEvent{TaskId: 1, Event: TaskEventFailed, Error: strings.Repeat("e", 1000)}
+		require.NoError(t, db.Create(&event).Error)
+		require.NoError(t, db.Where("task_id = ?", 1).Take(&event).Error)
+		require.Len(t, event.Error, 1000)
+	})
+}
+
+func TestMigrateOldSchema(t *testing.T) {
+	testDialects(t, func(t *testing.T, db *gorm.DB) {
+		// the schema created by the first release, without schema_version
+		for _, value := range []interface{}{&blockLogV1{}, &eventLogV1{}, &executionTaskV1{}} {
+			require.NoError(t, db.CreateTable(value).Error)
+		}
+		require.NoError(t, db.Table("block_log").AddUniqueIndex("idx_block_log_height", "height").Error)
+		require.NoError(t, db.Create(&executionTaskV1{TaskId: 1, GasUsed: 10, Status: 1}).Error)
+		// some columns added by the auto migration of a later release
+		require.NoError(t, db.Exec("ALTER TABLE execution_task ADD COLUMN operator varchar(255)").Error)
+		require.NoError(t, db.Exec("UPDATE execution_task SET operator = 'a'").Error)
+
+		require.NoError(t, Migrate(db))
+		version, err := CurrentSchemaVersion(db)
+		require.NoError(t, err)
+		require.Equal(t, Migrations[len(Migrations)-1].Version, version)
+		requireSchemaOfModels(t, db)
+		require.True(t, db.Dialect().HasIndex("execution_task", "idx_execution_task_status_operator"))
+
+		// the existing rows are kept, the new columns take the defaults
+		task := ExecutionTask{}
+		require.NoError(t, db.Where("task_id = ?", 1).Take(&task).Error)
+		require.Equal(t, "a", task.Operator)
+		require.Equal(t, int64(10), task.GasUsed)
+		require.Equal(t, ExecutionTaskStatusStatusExecuted, task.Status)
+		require.Equal(t, "", task.SubmitError)
+		require.Equal(t, 0, task.SubmitAttempts)
+	})
+}
+
+func TestMigrateFromVersion(t *testing.T) {
+	testDialects(t, func(t *testing.T, db *gorm.DB) {
+		migrations := Migrations
+		t.Cleanup(func() { Migrations = migrations })
+
+		// a database of an earlier version is migrated by the migrations after it
+		Migrations = migrations[:2]
+		require.NoError(t, Migrate(db))
+		require.False(t, db.Dialect().HasColumn("execution_task", "submit_error"))
+
+		Migrations = migrations
+		require.NoError(t, Migrate(db))
+		requireSchemaOfModels(t, db)
+
+		var versions []int
+		require.NoError(t, db.Model(&SchemaVersion{}).Order("version asc").Pluck("version", &versions).Error)
+		require.Len(t, versions, len(migrations))
+	})
+}
+
+func TestMigrateDuplicatedTaskIds(t *testing.T) {
+	testDialects(t, func(t *testing.T, db *gorm.DB) {
+		migrations := Migrations
+		t.Cleanup(func() { Migrations = migrations })
+
+		Migrations = migrations[:8]
+		require.NoError(t, Migrate(db))
+		for i := 0; i < 2; i++ {
+			require.NoError(t, db.Create(&executionTaskV1{TaskId: 1}).Error)
+		}
+
+		// the unique index is not added until the duplicates are resolved
+		Migrations = migrations
+		require.ErrorContains(t, Migrate(db), "duplicated task ids [1]")
+		require.NoError(t, db.Where("id = ?", 2).Delete(&ExecutionTask{}).Error)
+		require.NoError(t, Migrate(db))
+		require.True(t, db.Dialect().HasIndex("execution_task", "idx_execution_task_task_id"))
+	})
+}
@@ -1,9 +1,9 @@
 package model
 
 import (
+	"fmt"
+	"strconv"
 	"time"
-
-	"github.com/jinzhu/gorm"
 )
 
 type BlockLog struct {
@@ -69,14 +69,79 @@ type ExecutionTaskStatus int
 const (
 	ExecutionTaskStatusStatusInit             ExecutionTaskStatus = 0 // just created by observer
 	ExecutionTaskStatusStatusExecuted         ExecutionTaskStatus = 1 // executed by executor
-	ExecutionTaskStatusStatusReceiptSubmitted ExecutionTaskStatus = 2 // receipt submitted by sender
+	ExecutionTaskStatusStatusReceiptSubmitted ExecutionTaskStatus = 2 // receipt tx included on chain successfully
+	ExecutionTaskStatusStatusRejected         ExecutionTaskStatus = 3 // rejected by the admission policy of observer
+	ExecutionTaskStatusStatusReceiptPending   ExecutionTaskStatus = 4 // receipt tx broadcast by sender, waiting for inclusion
+	ExecutionTaskStatusStatusDeadLetter       ExecutionTaskStatus = 5 // execution or receipt failed permanently or after all attempts
+	ExecutionTaskStatusStatusOrphaned         ExecutionTaskStatus = 6 // its event reverted by a reorg after it was created
+)
+
+var executionTaskStatusNames = map[ExecutionTaskStatus]string{
+	ExecutionTaskStatusStatusInit:             "init",
+	ExecutionTaskStatusStatusExecuted:         "executed",
+	ExecutionTaskStatusStatusReceiptSubmitted: "receipt_submitted",
+	ExecutionTaskStatusStatusRejected:         "rejected",
+	ExecutionTaskStatusStatusReceiptPending:   "receipt_pending",
+	ExecutionTaskStatusStatusDeadLetter:       "dead_letter",
+	ExecutionTaskStatusStatusOrphaned:         "orphaned",
+}
+
+func (s ExecutionTaskStatus) String() string {
+	if name, ok := executionTaskStatusNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// ParseExecutionTaskStatus returns the status of the name, or of the number
+func ParseExecutionTaskStatus(name string) (ExecutionTaskStatus, error) {
+	for status, statusName := range executionTaskStatusNames {
+		if statusName == name {
+			return status, nil
+		}
+	}
+	status, err := strconv.Atoi(name)
+	if err != nil || executionTaskStatusNames[ExecutionTaskStatus(status)] == "" {
+		return 0, fmt.Errorf("unknown execution task status %s", name)
+	}
+	return ExecutionTaskStatus(status), nil
+}
+
+// ExecutionResultStatus is the status of the execution result submitted on chain. the chain takes 1 as
+// successful and the others as failed, the failure codes tell why the execution failed.
+type ExecutionResultStatus uint32
+
+const (
+	ExecutionResultStatusProviderFailure ExecutionResultStatus = 0 // the provider failed to run the task, e.g. no report produced
+	ExecutionResultStatusSuccess         ExecutionResultStatus = 1
+	ExecutionResultStatusTrap            ExecutionResultStatus = 2 // the executable aborted with an exception
+	ExecutionResultStatusOutOfGas        ExecutionResultStatus = 3 // the executable ran out of the max gas
+	ExecutionResultStatusTimeout         ExecutionResultStatus = 4 // the executable did not finish in time
+	ExecutionResultStatusRejected        ExecutionResultStatus = 5 // rejected by the admission policy of observer
 )
 
+var executionResultStatusNames = map[ExecutionResultStatus]string{
+	ExecutionResultStatusProviderFailure: "provider_failure",
+	ExecutionResultStatusSuccess:         "success",
+	ExecutionResultStatusTrap:            "trap",
+	ExecutionResultStatusOutOfGas:        "out_of_gas",
+	ExecutionResultStatusTimeout:         "timeout",
+	ExecutionResultStatusRejected:        "rejected",
+}
+
+func (s ExecutionResultStatus) String() string {
+	if name, ok := executionResultStatusNames[s]; ok {
+		return name
+	}
+	return strconv.FormatUint(uint64(s), 10)
+}
+
 type ExecutionTask struct {
 	Id int64
 
 	InvokeTxHash string
 	TaskId       int64
+	Operator     string
 
 	ExecutionObjectId string
 	ExecutionUri      string
@@ -87,11 +152,25 @@ type ExecutionTask struct {
 	Params       string // hex encoded
 
 	// results
-	GasUsed         int64
-	ExecutionStatus string
-	ResultDataUri   string
-	LogDataUri      string
-	SubmitTxHash    string
+	GasUsed           int64
+	ResultStatus      ExecutionResultStatus
+	ExecutionStatus   string // the message of the execution result
+	ResultDataUri     string
+	LogDataUri        string
+	AttestationUri    string // object id of the signed attestation document
+	LeaseOwner        string // the executor executing the task
+	LeaseExpireTime   int64  // the task can be claimed by another executor after then
+	ExecutionAttempts int    // the times the task is claimed for execution
+	SubmitAccount     string // address of the account submitting the result
+	SubmitTxHash      string
+	SubmitTxHeight    int64
+	SubmitTxCode      uint32
+	SubmitAttempts    int
+	SubmitTime        int64 // time of the last broadcast
+	NextSubmitTime    int64 // the result is not resubmitted before then
+	SubmitError       string
+	ResultSubmitter   string // address of the account whose result is accepted on chain, maybe not ours
+	RejectReason      string
 
 	Status     ExecutionTaskStatus
 	CreateTime int64
@@ -107,19 +186,3 @@ func (l *ExecutionTask) BeforeCreate() (err error) {
 	l.UpdateTime = time.Now().Unix()
 	return nil
 }
-
-func InitTables(db *gorm.DB) {
-	if !db.HasTable(&BlockLog{}) {
-		db.CreateTable(&BlockLog{})
-		db.Model(&BlockLog{}).AddUniqueIndex("idx_block_log_height", "height")
-		db.Model(&BlockLog{}).AddIndex("idx_block_log_create_time", "create_time")
-	}
-
-	if !db.HasTable(&EventLog{}) {
-		db.CreateTable(&EventLog{})
-	}
-
-	if !db.HasTable(&ExecutionTask{}) {
-		db.CreateTable(&ExecutionTask{})
-	}
-}
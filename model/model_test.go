@@ -0,0 +1,26 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExecutionTaskStatus(t *testing.T) {
+	for name, status := range map[string]ExecutionTaskStatus{
+		"init":            ExecutionTaskStatusStatusInit,
+		"receipt_pending": ExecutionTaskStatusStatusReceiptPending,
+		"dead_letter":     ExecutionTaskStatusStatusDeadLetter,
+		"1":               ExecutionTaskStatusStatusExecuted,
+	} {
+		parsed, err := ParseExecutionTaskStatus(name)
+		require.NoError(t, err)
+		require.Equal(t, status, parsed)
+	}
+	require.Equal(t, "receipt_submitted", ExecutionTaskStatusStatusReceiptSubmitted.String())
+
+	for _, name := range []string{"", "done", "9"} {
+		_, err := ParseExecutionTaskStatus(name)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"time"
+)
+
+type TaskEventType string
+
+const (
+	TaskEventCreated      TaskEventType = "created"       // created by observer
+	TaskEventRejected     TaskEventType = "rejected"      // rejected by the admission policy of observer
+	TaskEventReverted     TaskEventType = "reverted"      // deleted as its event is reverted by a reorg
+	TaskEventOrphaned     TaskEventType = "orphaned"      // its event is reverted by a reorg after it was executed or rejected
+	TaskEventClaimed      TaskEventType = "claimed"       // leased to an executor
+	TaskEventDownloaded   TaskEventType = "downloaded"    // the executable and inputs downloaded
+	TaskEventRan          TaskEventType = "ran"           // the executable ran in the container
+	TaskEventExecuted     TaskEventType = "executed"      // the results uploaded and the receipt saved
+	TaskEventFailed       TaskEventType = "failed"        // the execution failed, the task is claimed again after the lease expires
+	TaskEventSubmitted    TaskEventType = "submitted"     // receipt tx broadcast by sender
+	TaskEventIncluded     TaskEventType = "included"      // receipt tx included, or the result found on chain
+	TaskEventResubmit     TaskEventType = "resubmit"      // the submission failed, the result is submitted again after a backoff
+	TaskEventDeadLettered TaskEventType = "dead_lettered" // the execution or the submission failed permanently or after all attempts
+)
+
+// TaskEvent is a transition of an execution task, the events are only appended
+type TaskEvent struct {
+	Id     int64
+	TaskId int64
+	Event  TaskEventType
+	Status ExecutionTaskStatus // status of the task after the event
+	Worker string              // the executor, or the account of sender
+	Error  string              // why the task failed, or the detail of a failed execution
+
+	EventTime int64 // unix time in milliseconds, the phases may take less than a second
+}
+
+func (TaskEvent) TableName() string {
+	return "task_event"
+}
+
+func (e *TaskEvent) BeforeCreate() (err error) {
+	if e.EventTime == 0 {
+		e.EventTime = time.Now().UnixMilli()
+	}
+	return nil
+}
+
+// TaskPhases is the time an execution task spent in each phase, zero for the phases not finished
+type TaskPhases struct {
+	Queue      time.Duration // from creation to the first claim
+	Download   time.Duration // from the claim to the download of the executable and inputs
+	Execution  time.Duration // running the executable
+	Upload     time.Duration // uploading the results and saving the receipt
+	Submission time.Duration // from the receipt to the inclusion of the receipt tx
+}
+
+// TaskPhasesOf derives the phases of a task from its events in order of time, the execution phases are of the
+// last claim
+func TaskPhasesOf(events []TaskEvent) TaskPhases {
+	var created, firstClaimed, claimed, downloaded, ran, executed, included int64
+	for _, event := range events {
+		switch event.Event {
+		case TaskEventCreated:
+			created = event.EventTime
+		case TaskEventClaimed:
+			if firstClaimed == 0 {
+				firstClaimed = event.EventTime
+			}
+			claimed, downloaded, ran = event.EventTime, 0, 0
+		case TaskEventDownloaded:
+			downloaded = event.EventTime
+		case TaskEventRan:
+			ran = event.EventTime
+		case TaskEventExecuted:
+			executed = event.EventTime
+		case TaskEventIncluded:
+			included = event.EventTime
+		}
+	}
+
+	return TaskPhases{
+		Queue:      phase(created, firstClaimed),
+		Download:   phase(claimed, downloaded),
+		Execution:  phase(downloaded, ran),
+		Upload:     phase(ran, executed),
+		Submission: phase(executed, included),
+	}
+}
+
+// phase returns the duration between the times in milliseconds, zero if either is missing
+func phase(start int64, end int64) time.Duration {
+	if start == 0 || end < start {
+		return 0
+	}
+	return time.Duration(end-start) * time.Millisecond
+}
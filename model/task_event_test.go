@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskPhasesOf(t *testing.T) {
+	events := []TaskEvent{
+		{Event: TaskEventCreated, EventTime: 1000},
+		{Event: TaskEventClaimed, EventTime: 3000},
+		{Event: TaskEventFailed, EventTime: 3500},
+		// claimed again after the lease expires
+		{Event: TaskEventClaimed, EventTime: 10000},
+		{Event: TaskEventDownloaded, EventTime: 10200},
+		{Event: TaskEventRan, EventTime: 15200},
+		{Event: TaskEventExecuted, EventTime: 16000},
+		{Event: TaskEventSubmitted, EventTime: 17000},
+		{Event: TaskEventIncluded, EventTime: 20000},
+	}
+	require.Equal(t, TaskPhases{
+		Queue:      2 * time.Second,
+		Download:   200 * time.Millisecond,
+		Execution:  5 * time.Second,
+		Upload:     800 * time.Millisecond,
+		Submission: 4 * time.Second,
+	}, TaskPhasesOf(events))
+
+	// the phases not finished are zero
+	require.Equal(t, TaskPhases{Queue: 2 * time.Second, Download: 200 * time.Millisecond}, TaskPhasesOf(events[:5]))
+	require.Equal(t, TaskPhases{}, TaskPhasesOf(nil))
+}
@@ -0,0 +1,80 @@
+package observer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// BucketResolver resolves the bucket of an object
+type BucketResolver interface {
+	GetObjectBucketName(objectId string) (string, error)
+}
+
+// admitExecutionTask evaluates the admission policy on the task event, it returns the reason if the
+// task is rejected. an error is returned if the policy can not be evaluated for now.
+func (ob *Observer) admitExecutionTask(eventLog *model.EventLog) (string, error) {
+	cfg := ob.Config.AdmissionConfig
+	if cfg == nil {
+		return "", nil
+	}
+
+	if !admittedByLists(eventLog.Operator, cfg.AllowedOperators, cfg.DeniedOperators) {
+		return fmt.Sprintf("operator %s not admitted", eventLog.Operator), nil
+	}
+
+	if !admittedByLists(eventLog.ExecutableObjectId, cfg.AllowedExecutables, cfg.DeniedExecutables) {
+		return fmt.Sprintf("executable %s not admitted", eventLog.ExecutableObjectId), nil
+	}
+
+	if len(cfg.AllowedBuckets) != 0 || len(cfg.DeniedBuckets) != 0 {
+		resolver, ok := ob.Client.(BucketResolver)
+		if !ok {
+			return "", fmt.Errorf("block source can not resolve buckets of executables")
+		}
+		bucketName, err := resolver.GetObjectBucketName(eventLog.ExecutableObjectId)
+		if err != nil {
+			return "", fmt.Errorf("get bucket of executable error, object_id=%s, err=%s", eventLog.ExecutableObjectId, err.Error())
+		}
+		if !admittedByLists(bucketName, cfg.AllowedBuckets, cfg.DeniedBuckets) {
+			return fmt.Sprintf("bucket %s of executable not admitted", bucketName), nil
+		}
+	}
+
+	if cfg.MinMaxGas != 0 || cfg.MaxMaxGas != 0 {
+		maxGas, err := strconv.ParseUint(eventLog.MaxGas, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid max gas %s", eventLog.MaxGas), nil
+		}
+		if maxGas < cfg.MinMaxGas {
+			return fmt.Sprintf("max gas %d lower than %d", maxGas, cfg.MinMaxGas), nil
+		}
+		if cfg.MaxMaxGas != 0 && maxGas > cfg.MaxMaxGas {
+			return fmt.Sprintf("max gas %d higher than %d", maxGas, cfg.MaxMaxGas), nil
+		}
+	}
+
+	if cfg.MaxInputCount != 0 {
+		inputObjectIds := make([]string, 0)
+		if err := json.Unmarshal([]byte(eventLog.InputObjectIds), &inputObjectIds); err != nil {
+			return fmt.Sprintf("invalid input object ids %s", eventLog.InputObjectIds), nil
+		}
+		if len(inputObjectIds) > cfg.MaxInputCount {
+			return fmt.Sprintf("input count %d more than %d", len(inputObjectIds), cfg.MaxInputCount), nil
+		}
+	}
+
+	return "", nil
+}
+
+// admittedByLists returns false if the value is in the deny list, or the allow list is not empty
+// and the value is not in it
+func admittedByLists(value string, allowList, denyList []string) bool {
+	if util.Contains(denyList, value) {
+		return false
+	}
+	return len(allowList) == 0 || util.Contains(allowList, value)
+}
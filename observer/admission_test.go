@@ -0,0 +1,107 @@
+package observer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// fakeBucketSource resolves the buckets of objects by the map
+type fakeBucketSource struct {
+	*fakeBlockSource
+	buckets map[string]string
+}
+
+func (f *fakeBucketSource) GetObjectBucketName(objectId string) (string, error) {
+	bucketName, ok := f.buckets[objectId]
+	if !ok {
+		return "", fmt.Errorf("object not found")
+	}
+	return bucketName, nil
+}
+
+func TestAdmitExecutionTask(t *testing.T) {
+	source := &fakeBucketSource{
+		fakeBlockSource: newFakeBlockSource(),
+		buckets:         map[string]string{"1": "good-bucket", "2": "bad-bucket"},
+	}
+	ob := newTestObserver(t, source)
+
+	newEventLog := func() *model.EventLog {
+		return &model.EventLog{
+			Operator:           "0xOperator",
+			ExecutableObjectId: "1",
+			InputObjectIds:     `["10","11"]`,
+			MaxGas:             "1000",
+		}
+	}
+
+	cases := []struct {
+		name     string
+		cfg      util.AdmissionConfig
+		modify   func(eventLog *model.EventLog)
+		rejected bool
+	}{
+		{name: "no policy", cfg: util.AdmissionConfig{}},
+		{name: "allowed operator", cfg: util.AdmissionConfig{AllowedOperators: []string{"0xOperator"}}},
+		{name: "operator not allowed", cfg: util.AdmissionConfig{AllowedOperators: []string{"0xOther"}}, rejected: true},
+		{name: "denied operator", cfg: util.AdmissionConfig{DeniedOperators: []string{"0xOperator"}}, rejected: true},
+		{name: "denied executable", cfg: util.AdmissionConfig{DeniedExecutables: []string{"1"}}, rejected: true},
+		{name: "allowed bucket", cfg: util.AdmissionConfig{AllowedBuckets: []string{"good-bucket"}}},
+		{
+			name:     "denied bucket",
+			cfg:      util.AdmissionConfig{DeniedBuckets: []string{"bad-bucket"}},
+			modify:   func(eventLog *model.EventLog) { eventLog.ExecutableObjectId = "2" },
+			rejected: true,
+		},
+		{name: "max gas too low", cfg: util.AdmissionConfig{MinMaxGas: 2000}, rejected: true},
+		{name: "max gas too high", cfg: util.AdmissionConfig{MaxMaxGas: 500}, rejected: true},
+		{name: "max gas in range", cfg: util.AdmissionConfig{MinMaxGas: 500, MaxMaxGas: 2000}},
+		{name: "too many inputs", cfg: util.AdmissionConfig{MaxInputCount: 1}, rejected: true},
+		{name: "inputs in limit", cfg: util.AdmissionConfig{MaxInputCount: 2}},
+	}
+
+	for _, c := range cases {
+		cfg := c.cfg
+		ob.Config.AdmissionConfig = &cfg
+		eventLog := newEventLog()
+		if c.modify != nil {
+			c.modify(eventLog)
+		}
+
+		reason, err := ob.admitExecutionTask(eventLog)
+		require.NoError(t, err, c.name)
+		require.Equal(t, c.rejected, reason != "", c.name)
+	}
+
+	// the policy can not be evaluated if the bucket is unknown
+	ob.Config.AdmissionConfig = &util.AdmissionConfig{AllowedBuckets: []string{"good-bucket"}}
+	eventLog := newEventLog()
+	eventLog.ExecutableObjectId = "3"
+	_, err := ob.admitExecutionTask(eventLog)
+	require.Error(t, err)
+}
+
+func TestRejectedTaskRecorded(t *testing.T) {
+	source := newFakeBlockSource()
+	source.extend(1, 2, "a", map[int64]int64{1: 1, 2: 2})
+
+	ob := newTestObserver(t, source)
+	syncTo(t, ob, 1, 2)
+
+	ob.Config.AdmissionConfig = &util.AdmissionConfig{MaxInputCount: 1}
+	require.NoError(t, testDB(ob).Model(model.EventLog{}).Where("task_id = ?", 1).Update("input_object_ids", `["10","11"]`).Error)
+	require.NoError(t, testDB(ob).Model(model.EventLog{}).Where("task_id = ?", 2).Update("input_object_ids", `["10"]`).Error)
+	processTaskEvents(t, ob)
+
+	var tasks []model.ExecutionTask
+	require.NoError(t, testDB(ob).Order("task_id asc").Find(&tasks).Error)
+	require.Len(t, tasks, 2)
+	require.Equal(t, model.ExecutionTaskStatusStatusRejected, tasks[0].Status)
+	require.Equal(t, "input count 2 more than 1", tasks[0].RejectReason)
+	require.Equal(t, model.ExecutionTaskStatusStatusInit, tasks[1].Status)
+}
@@ -0,0 +1,28 @@
+package observer
+
+import (
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// RPCChecker reports whether the rpc endpoints of the block source are reachable
+type RPCChecker interface {
+	Reachable() error
+}
+
+// LivenessChecks returns the heartbeat of the fetch routine
+func (ob *Observer) LivenessChecks() map[string]util.HealthCheck {
+	return map[string]util.HealthCheck{
+		"fetch": ob.fetchHeartbeat.Check,
+	}
+}
+
+// ReadinessChecks returns whether a block is fetched recently and the rpc endpoints are reachable
+func (ob *Observer) ReadinessChecks() map[string]util.HealthCheck {
+	checks := map[string]util.HealthCheck{
+		"sync": ob.syncHeartbeat.Check,
+	}
+	if checker, ok := ob.Client.(RPCChecker); ok {
+		checks["rpc"] = checker.Reachable
+	}
+	return checks
+}
@@ -0,0 +1,40 @@
+package observer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+func TestSyncHeartbeat(t *testing.T) {
+	source := newFakeBlockSource()
+	source.extend(1, 3, "a", nil)
+
+	ob := newTestObserver(t, source)
+	ob.syncHeartbeat = util.NewHeartbeat(50 * time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	require.Error(t, ob.ReadinessChecks()["sync"]())
+	_, ok := ob.ReadinessChecks()["rpc"]
+	require.False(t, ok)
+
+	// a block fetched
+	syncTo(t, ob, 1, 2)
+	require.NoError(t, ob.ReadinessChecks()["sync"]())
+
+	// the chain tip reached
+	time.Sleep(100 * time.Millisecond)
+	curBlockLog, err := ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.NoError(t, ob.fetchNext(curBlockLog, curBlockLog.Height+1))
+	require.Equal(t, int64(3), ob.latestHeight)
+	require.NoError(t, ob.ReadinessChecks()["sync"]())
+
+	time.Sleep(100 * time.Millisecond)
+	curBlockLog, err = ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.Error(t, ob.fetchNext(curBlockLog, curBlockLog.Height+1))
+	require.NoError(t, ob.ReadinessChecks()["sync"]())
+}
@@ -0,0 +1,61 @@
+package observer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+)
+
+var (
+	heightGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "observer",
+		Name:      "height",
+		Help:      "The highest block saved.",
+	})
+	chainTipGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "observer",
+		Name:      "chain_tip",
+		Help:      "The latest height of greenfield known by the observer.",
+	})
+	lagGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "observer",
+		Name:      "lag_blocks",
+		Help:      "The blocks the observer is behind the chain tip.",
+	})
+	reorgCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "observer",
+		Name:      "reorgs_total",
+		Help:      "The reorgs rolled back.",
+	})
+	eventCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "observer",
+		Name:      "events_total",
+		Help:      "The events by type and status, observed when saved, processed or failed when handled.",
+	}, []string{"event", "status"})
+)
+
+const (
+	eventStatusObserved  = "observed"
+	eventStatusProcessed = "processed"
+	eventStatusFailed    = "failed"
+)
+
+// reportHeight updates the height metrics with the highest block saved
+func (ob *Observer) reportHeight(height int64) {
+	heightGauge.Set(float64(height))
+	if ob.latestHeight == 0 {
+		return
+	}
+	chainTipGauge.Set(float64(ob.latestHeight))
+	if ob.latestHeight > height {
+		lagGauge.Set(float64(ob.latestHeight - height))
+	} else {
+		lagGauge.Set(0)
+	}
+}
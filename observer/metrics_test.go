@@ -0,0 +1,34 @@
+package observer
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+)
+
+func TestMetrics(t *testing.T) {
+	source := newFakeBlockSource()
+	source.extend(1, 10, "a", map[int64]int64{3: 1, 5: 2})
+
+	observed := testutil.ToFloat64(eventCounter.WithLabelValues(common.ExecutionTaskEvent, eventStatusObserved))
+	ob := newTestObserver(t, source)
+	ob.latestHeight = 10
+	syncTo(t, ob, 1, 6)
+	require.Equal(t, float64(6), testutil.ToFloat64(heightGauge))
+	require.Equal(t, float64(10), testutil.ToFloat64(chainTipGauge))
+	require.Equal(t, float64(4), testutil.ToFloat64(lagGauge))
+	require.Equal(t, observed+2, testutil.ToFloat64(eventCounter.WithLabelValues(common.ExecutionTaskEvent, eventStatusObserved)))
+
+	// the blocks after 4 are replaced by another branch
+	reorgs := testutil.ToFloat64(reorgCounter)
+	source.extend(5, 12, "b", nil)
+	curBlockLog, err := ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.NoError(t, ob.fetchBlock(curBlockLog.Height, curBlockLog.Height+1, curBlockLog.BlockHash))
+	require.Equal(t, reorgs+1, testutil.ToFloat64(reorgCounter))
+	require.Equal(t, float64(4), testutil.ToFloat64(heightGauge))
+	require.Equal(t, float64(6), testutil.ToFloat64(lagGauge))
+}
@@ -1,43 +1,91 @@
 package observer
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/jinzhu/gorm"
-
-	"github.com/bnb-chain/greenfield-execution-provider/client"
+	"github.com/bnb-chain/greenfield-execution-provider/archive"
 	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/event"
 	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
 	"github.com/bnb-chain/greenfield-execution-provider/util"
 )
 
+// BlockSource provides the blocks and the execution events of greenfield
+type BlockSource interface {
+	GetLatestHeight() (int64, error)
+	GetBlockAndEventsAtHeight(height int64) (*common.BlockAndEventLogs, error)
+}
+
+// HeightSubscriber notifies the heights of the new blocks of greenfield
+type HeightSubscriber interface {
+	SubscribeNewHeights(ctx context.Context) (<-chan int64, error)
+}
+
 type Observer struct {
-	DB     *gorm.DB
-	Config *util.ObserverConfig
-	Client *client.GreenfieldClient
+	Store    store.Store
+	Config   *util.ObserverConfig
+	Client   BlockSource
+	Archiver archive.Archiver // archives the pruned rows, nil to disable archival
+	Registry *event.Registry  // the event types observed and how they are handled
+
+	latestHeight int64      // the latest chain height known by the fetch routine
+	newHeightCh  chan int64 // the heights notified by the subscription
+
+	fetchHeartbeat *util.Heartbeat // beaten on each round of the fetch routine
+	syncHeartbeat  *util.Heartbeat // beaten when a block is fetched or the chain tip is reached
 }
 
-// NewObserver returns the observer instance
-func NewObserver(db *gorm.DB, cfg *util.ObserverConfig, client *client.GreenfieldClient) *Observer {
-	return &Observer{
-		DB:     db,
-		Config: cfg,
-		Client: client,
+// NewObserver returns the observer instance, the handlers of execution events are registered to the
+// registry unless they are registered already
+func NewObserver(st store.Store, cfg *util.ObserverConfig, client BlockSource, archiver archive.Archiver, registry *event.Registry) *Observer {
+	ob := &Observer{
+		Store:       st,
+		Config:      cfg,
+		Client:      client,
+		Archiver:    archiver,
+		Registry:    registry,
+		newHeightCh: make(chan int64, 1),
+
+		fetchHeartbeat: util.NewHeartbeat(common.HealthHeartbeatTimeout),
+		syncHeartbeat:  util.NewHeartbeat(common.HealthHeartbeatTimeout),
+	}
+
+	if !registry.IsRegistered(common.ExecutionTaskEvent) {
+		registry.Register(common.ExecutionTaskEvent, event.Registration{
+			Decoder: event.DecodeExecutionTask,
+			Handler: ob.handleExecutionTask,
+		})
 	}
+	if !registry.IsRegistered(common.ExecutionResultEvent) {
+		registry.Register(common.ExecutionResultEvent, event.Registration{})
+	}
+	return ob
 }
 
 // Start starts the routines of observer
 func (ob *Observer) Start() {
+	if ob.Config.UseWebsocket {
+		if subscriber, ok := ob.Client.(HeightSubscriber); ok {
+			go ob.Subscribe(subscriber)
+		} else {
+			util.Logger.Errorf("block source does not support subscription, fallback to polling")
+		}
+	}
 	go ob.Fetch(ob.Config.GreenfieldConfig.StartHeight)
 	go ob.ProcessConfirmedEvent()
 	go ob.PruneBlocks()
+	go ob.PruneEventsAndTasks()
 	go ob.Alert()
 }
 
-// Fetch starts the main routine for fetching blocks of BSC
+// Fetch starts the main routine for fetching blocks of greenfield
 func (ob *Observer) Fetch(startHeight int64) {
 	for {
+		ob.fetchHeartbeat.Beat()
 		curBlockLog, err := ob.GetCurrentBlockLog()
 		if err != nil {
 			util.Logger.Errorf("get current block log error, err=%s", err.Error())
@@ -50,17 +98,168 @@ func (ob *Observer) Fetch(startHeight int64) {
 			nextHeight = startHeight
 		}
 
-		util.Logger.Infof("fetch block, height=%d", nextHeight)
-		err = ob.fetchBlock(curBlockLog.Height, nextHeight, curBlockLog.BlockHash)
+		err = ob.fetchNext(curBlockLog, nextHeight)
 		if err != nil {
 			util.Logger.Errorf("fetch block error, err=%s", err.Error())
-			time.Sleep(common.ObserverFetchInterval)
+			ob.waitForNewBlock()
+		}
+	}
+}
+
+// waitForNewBlock waits until a new block is notified by the subscription or the fetch interval elapses
+func (ob *Observer) waitForNewBlock() {
+	select {
+	case height := <-ob.newHeightCh:
+		if height > ob.latestHeight {
+			ob.latestHeight = height
+		}
+	case <-time.After(common.ObserverFetchInterval):
+	}
+}
+
+// Subscribe starts the routine for subscribing to new blocks, the fetch routine is woken up as soon as
+// a new block is produced. the subscription is renewed if it is broken, and the fetch routine keeps
+// polling in the meantime.
+func (ob *Observer) Subscribe(subscriber HeightSubscriber) {
+	var lastHeight int64
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		heights, err := subscriber.SubscribeNewHeights(ctx)
+		if err != nil {
+			util.Logger.Errorf("subscribe new blocks error, fallback to polling, err=%s", err.Error())
+			cancel()
+			time.Sleep(common.ObserverResubscribeInterval)
+			continue
+		}
+
+		util.Logger.Infof("new blocks subscribed, last subscribed height=%d", lastHeight)
+		lastHeight = ob.receiveHeights(heights, lastHeight)
+		cancel()
+
+		util.Logger.Errorf("subscription of new blocks broken, fallback to polling, last subscribed height=%d", lastHeight)
+		time.Sleep(common.ObserverResubscribeInterval)
+	}
+}
+
+// receiveHeights notifies the fetch routine of the subscribed heights until the subscription is closed
+// or no height is received in time, and returns the last received height. the blocks missed by the
+// subscription are fetched by the fetch routine in catch-up mode.
+func (ob *Observer) receiveHeights(heights <-chan int64, lastHeight int64) int64 {
+	for {
+		select {
+		case height, ok := <-heights:
+			if !ok {
+				return lastHeight
+			}
+			if lastHeight != 0 && height > lastHeight+1 {
+				util.Logger.Infof("gap detected in subscribed blocks, from=%d, to=%d", lastHeight+1, height-1)
+			}
+			if height > lastHeight {
+				lastHeight = height
+			}
+
+			select {
+			case ob.newHeightCh <- height:
+			default:
+				// the fetch routine is busy and will refresh the chain tip by itself
+			}
+		case <-time.After(common.ObserverSubscribeTimeout):
+			return lastHeight
+		}
+	}
+}
+
+// fetchNext fetches the blocks following the current block. if the observer is more than one block
+// behind the chain, it switches to catch-up mode and fetches a window of blocks concurrently,
+// otherwise it follows the tip block by block.
+func (ob *Observer) fetchNext(curBlockLog *model.BlockLog, nextHeight int64) error {
+	// the chain tip is only refreshed when it is reached, so tip-following costs no extra rpc call
+	if nextHeight > ob.latestHeight {
+		latestHeight, err := ob.Client.GetLatestHeight()
+		if err != nil {
+			util.Logger.Errorf("get latest height error, err=%s", err.Error())
+		} else {
+			ob.latestHeight = latestHeight
+			ob.reportHeight(curBlockLog.Height)
+			if curBlockLog.Height >= latestHeight {
+				ob.syncHeartbeat.Beat()
+			}
+		}
+	}
+
+	// avoid requesting blocks not produced yet, they fail on every endpoint
+	if ob.latestHeight != 0 && nextHeight > ob.latestHeight {
+		return fmt.Errorf("block not produced yet, height=%d, latest height=%d", nextHeight, ob.latestHeight)
+	}
+
+	window := ob.Config.GetCatchUpWindow()
+	if window > 1 && ob.latestHeight > nextHeight {
+		toHeight := nextHeight + window - 1
+		if toHeight > ob.latestHeight {
+			toHeight = ob.latestHeight
 		}
+
+		util.Logger.Infof("catch up blocks, from=%d, to=%d, latest=%d", nextHeight, toHeight, ob.latestHeight)
+		return ob.fetchBlocks(curBlockLog.Height, nextHeight, toHeight, curBlockLog.BlockHash)
 	}
+
+	util.Logger.Infof("fetch block, height=%d", nextHeight)
+	return ob.fetchBlock(curBlockLog.Height, nextHeight, curBlockLog.BlockHash)
 }
 
-// fetchBlock fetches the next block of BSC and saves it to database. if the next block hash
-// does not match to the parent hash, the current block will be deleted for there is a fork.
+// fetchBlocks fetches the blocks in [fromHeight, toHeight] concurrently, then verifies the hash chain
+// in order and saves the blocks to database. the blocks are saved until the first failed or
+// mismatched one, the rest will be fetched again in the next round.
+func (ob *Observer) fetchBlocks(curHeight, fromHeight, toHeight int64, curBlockHash string) error {
+	blocks := make([]*common.BlockAndEventLogs, toHeight-fromHeight+1)
+	errs := make([]error, len(blocks))
+
+	var wg sync.WaitGroup
+	for idx := range blocks {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			blocks[idx], errs[idx] = ob.Client.GetBlockAndEventsAtHeight(fromHeight + int64(idx))
+		}(idx)
+	}
+	wg.Wait()
+
+	if errs[0] != nil {
+		return fmt.Errorf("get block info error, height=%d, err=%s", fromHeight, errs[0].Error())
+	}
+	if curHeight != 0 && blocks[0].ParentBlockHash != curBlockHash {
+		return ob.Rollback(curHeight)
+	}
+
+	var savedHeight int64
+	for idx, blockAndEventLogs := range blocks {
+		if errs[idx] != nil {
+			util.Logger.Errorf("get block info error, height=%d, err=%s", fromHeight+int64(idx), errs[idx].Error())
+			break
+		}
+		if idx > 0 && blockAndEventLogs.ParentBlockHash != blocks[idx-1].BlockHash {
+			util.Logger.Infof("block hash chain broken in catch-up window, height=%d", blockAndEventLogs.Height)
+			break
+		}
+
+		blockLog := model.BlockLog{
+			BlockHash:  blockAndEventLogs.BlockHash,
+			ParentHash: blockAndEventLogs.ParentBlockHash,
+			Height:     blockAndEventLogs.Height,
+			BlockTime:  blockAndEventLogs.BlockTime,
+		}
+		if err := ob.SaveBlockAndEvents(&blockLog, blockAndEventLogs.Events); err != nil {
+			return err
+		}
+		savedHeight = blockLog.Height
+	}
+
+	return ob.UpdateConfirmedNum(savedHeight)
+}
+
+// fetchBlock fetches the next block of greenfield and saves it to database. if the next block hash
+// does not match to the parent hash, there is a fork and the local chain will be rolled back to
+// the common ancestor.
 func (ob *Observer) fetchBlock(curHeight, nextHeight int64, curBlockHash string) error {
 	blockAndEventLogs, err := ob.Client.GetBlockAndEventsAtHeight(nextHeight)
 	if err != nil {
@@ -69,7 +268,7 @@ func (ob *Observer) fetchBlock(curHeight, nextHeight int64, curBlockHash string)
 
 	parentHash := blockAndEventLogs.ParentBlockHash
 	if curHeight != 0 && parentHash != curBlockHash {
-		return ob.DeleteBlockAndEvents(curHeight)
+		return ob.Rollback(curHeight)
 	} else {
 		nextBlockLog := model.BlockLog{
 			BlockHash:  blockAndEventLogs.BlockHash,
@@ -91,50 +290,78 @@ func (ob *Observer) fetchBlock(curHeight, nextHeight int64, curBlockHash string)
 	return nil
 }
 
-// DeleteBlockAndEvents deletes the block and txs of the given height
-func (ob *Observer) DeleteBlockAndEvents(height int64) error {
-	tx := ob.DB.Begin()
-	if err := tx.Error; err != nil {
+// Rollback walks back from the given height to the common ancestor of the local chain and the
+// canonical chain, then reverts the blocks, events and not yet executed tasks of the orphaned blocks
+func (ob *Observer) Rollback(height int64) error {
+	ancestorHeight, err := ob.findCommonAncestor(height)
+	if err != nil {
 		return err
 	}
 
-	if err := tx.Where("height = ?", height).Delete(model.BlockLog{}).Error; err != nil {
-		tx.Rollback()
-		return err
+	depth := height - ancestorHeight
+	util.Logger.Infof("reorg detected, height=%d, common ancestor height=%d, depth=%d", height, ancestorHeight, depth)
+	reorgCounter.Inc()
+	if ob.Config.AlertConfig.ReorgAlertDepth > 0 && depth > ob.Config.AlertConfig.ReorgAlertDepth {
+		msg := fmt.Sprintf("[%s] greenfield reorg detected, height=%d, common ancestor height=%d, depth=%d",
+			ob.Config.AlertConfig.Moniker, height, ancestorHeight, depth)
+		util.SendSlackMessage(msg)
 	}
 
-	if err := tx.Where("height = ? and status = ?", height, model.EventStatusInit).Delete(model.EventLog{}).Error; err != nil {
-		tx.Rollback()
+	if err := ob.DeleteBlocksAndEventsAbove(ancestorHeight); err != nil {
 		return err
 	}
-
-	return tx.Commit().Error
+	ob.reportHeight(ancestorHeight)
+	return nil
 }
 
-// UpdateConfirmedNum updates confirmation number of events
-func (ob *Observer) UpdateConfirmedNum(height int64) error {
-	err := ob.DB.Model(model.EventLog{}).Where("status = ?", model.EventStatusInit).Updates(
-		map[string]interface{}{
-			"confirmed_num": gorm.Expr("? - height", height+1),
-			"update_time":   time.Now().Unix(),
-		}).Error
-	if err != nil {
-		return err
+// findCommonAncestor returns the highest height not higher than the given height at which the local
+// block matches the canonical block. if the local block is missing(e.g. pruned or before the start
+// height), the height is treated as the common ancestor for there is nothing to compare with.
+func (ob *Observer) findCommonAncestor(height int64) (int64, error) {
+	for ; height > 0; height-- {
+		blockLog, err := ob.Store.GetBlockLog(height)
+		if err == store.ErrNotFound {
+			return height, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		block, err := ob.Client.GetBlockAndEventsAtHeight(height)
+		if err != nil {
+			return 0, fmt.Errorf("get block info error, height=%d, err=%s", height, err.Error())
+		}
+		if block.BlockHash == blockLog.BlockHash {
+			return height, nil
+		}
 	}
+	return 0, nil
+}
 
-	err = ob.DB.Model(model.EventLog{}).Where("status = ? and confirmed_num >= ?",
-		model.EventStatusInit, common.DefaultConfirmNum).Updates(
-		map[string]interface{}{
-			"status":      model.EventStatusConfirmed,
-			"update_time": time.Now().Unix(),
-		}).Error
+// DeleteBlocksAndEventsAbove deletes the blocks and events higher than the given height, and the
+// tasks created from the deleted events which have not been executed yet. the other tasks are orphaned
+func (ob *Observer) DeleteBlocksAndEventsAbove(height int64) error {
+	executedTasks, err := ob.Store.DeleteBlocksAndEventsAbove(height)
 	if err != nil {
 		return err
 	}
 
+	for _, task := range executedTasks {
+		msg := fmt.Sprintf("[%s] task of orphaned block has already been executed, it is not submitted, task_id=%d, status=%s",
+			ob.Config.AlertConfig.Moniker, task.TaskId, task.Status)
+		util.Logger.Error(msg)
+		util.SendSlackMessage(msg)
+	}
 	return nil
 }
 
+// UpdateConfirmedNum confirms the events which reach the confirmation number at the given height,
+// only the events crossing the threshold are touched
+func (ob *Observer) UpdateConfirmedNum(height int64) error {
+	confirmNum := ob.Config.GetConfirmNum()
+	return ob.Store.ConfirmEvents(height, confirmNum)
+}
+
 // PruneBlocks prunes the outdated blocks
 func (ob *Observer) PruneBlocks() {
 	for {
@@ -146,43 +373,164 @@ func (ob *Observer) PruneBlocks() {
 			continue
 		}
 
-		err = ob.DB.Where("height < ?", curBlockLog.Height-common.ObserverMaxBlockNumber).Delete(model.BlockLog{}).Error
+		err = ob.Store.PruneBlockLogs(curBlockLog.Height - common.ObserverMaxBlockNumber)
 		if err != nil {
 			util.Logger.Infof("prune block logs error, err=%s", err.Error())
 		}
 	}
 }
 
+// PruneEventsAndTasks prunes the event logs and execution tasks by the retention policies
+func (ob *Observer) PruneEventsAndTasks() {
+	if ob.Config.PruneConfig == nil {
+		return
+	}
+
+	for {
+		time.Sleep(common.ObserverPruneInterval)
+
+		for _, policy := range ob.Config.PruneConfig.EventLogPolicies {
+			if err := ob.pruneEventLogs(policy); err != nil {
+				util.Logger.Errorf("prune event logs error, status=%d, err=%s", policy.Status, err.Error())
+			}
+		}
+
+		for _, policy := range ob.Config.PruneConfig.ExecutionTaskPolicies {
+			if err := ob.pruneExecutionTasks(policy); err != nil {
+				util.Logger.Errorf("prune execution tasks error, status=%d, err=%s", policy.Status, err.Error())
+			}
+		}
+	}
+}
+
+// pruneEventLogs archives and deletes the outdated event logs in batches
+func (ob *Observer) pruneEventLogs(policy util.RetentionPolicy) error {
+	batchSize := ob.Config.PruneConfig.GetBatchSize()
+	for {
+		eventLogs, err := ob.Store.OutdatedEventLogs(model.EventStatus(policy.Status), time.Now().Unix()-policy.Retention, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(eventLogs) == 0 {
+			return nil
+		}
+
+		rows := make([]interface{}, 0, len(eventLogs))
+		ids := make([]int64, 0, len(eventLogs))
+		for idx := range eventLogs {
+			rows = append(rows, &eventLogs[idx])
+			ids = append(ids, eventLogs[idx].Id)
+		}
+		if err := ob.archiveAndDelete(model.EventLog{}.TableName(), policy.Status, ids, rows, ob.Store.DeleteEventLogs); err != nil {
+			return err
+		}
+
+		if len(eventLogs) < batchSize {
+			return nil
+		}
+	}
+}
+
+// pruneExecutionTasks archives and deletes the outdated execution tasks in batches
+func (ob *Observer) pruneExecutionTasks(policy util.RetentionPolicy) error {
+	batchSize := ob.Config.PruneConfig.GetBatchSize()
+	for {
+		tasks, err := ob.Store.OutdatedTasks(model.ExecutionTaskStatus(policy.Status), time.Now().Unix()-policy.Retention, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+
+		rows := make([]interface{}, 0, len(tasks))
+		ids := make([]int64, 0, len(tasks))
+		for idx := range tasks {
+			rows = append(rows, &tasks[idx])
+			ids = append(ids, tasks[idx].Id)
+		}
+		if err := ob.archiveAndDelete(model.ExecutionTask{}.TableName(), policy.Status, ids, rows, ob.Store.DeleteTasks); err != nil {
+			return err
+		}
+
+		if len(tasks) < batchSize {
+			return nil
+		}
+	}
+}
+
+// archiveAndDelete archives the rows if archival is enabled, then deletes them by the delete function of the table
+func (ob *Observer) archiveAndDelete(table string, status int, ids []int64, rows []interface{}, deleteRows func(ids []int64) error) error {
+	if ob.Archiver != nil {
+		name := fmt.Sprintf("%s_status%d_%d_%d", table, status, ids[0], ids[len(ids)-1])
+		if err := ob.Archiver.Archive(name, rows); err != nil {
+			return fmt.Errorf("archive rows error, table=%s, err=%s", table, err.Error())
+		}
+	}
+
+	if err := deleteRows(ids); err != nil {
+		return err
+	}
+	util.Logger.Infof("rows pruned, table=%s, status=%d, count=%d", table, status, len(ids))
+	return nil
+}
+
+// ProcessConfirmedEvent starts the routines for processing the confirmed events of each registered event type
 func (ob *Observer) ProcessConfirmedEvent() {
-	go ob.processConfirmedEvent(common.ExecutionTaskEvent)
-	go ob.processConfirmedEvent(common.ExecutionResultEvent)
+	for _, eventType := range ob.Registry.EventTypes() {
+		go ob.processConfirmedEvent(eventType)
+	}
 }
 
 func (ob *Observer) processConfirmedEvent(eventType string) {
 	for {
 		time.Sleep(common.ObserverFetchInterval)
 
-		eventLog := model.EventLog{}
-		err := ob.DB.Where("status = ? and event_name = ?", model.EventStatusConfirmed, eventType).Order("task_id asc").Take(&eventLog).Error
+		eventLog, err := ob.Store.NextConfirmedEvent(eventType)
 		if err != nil {
 			continue
 		}
 
-		switch eventType {
-		case common.ExecutionTaskEvent:
-			err := ob.processExecutionTask(eventLog)
-			if err != nil {
-				util.Logger.Errorf("process execution task error, err=%s", err.Error())
-				continue
-			}
+		err = ob.processEvent(*eventLog)
+		if err != nil {
+			util.Logger.Errorf("process event error, event=%s, id=%d, err=%s", eventType, eventLog.Id, err.Error())
+			eventCounter.WithLabelValues(eventType, eventStatusFailed).Inc()
+			continue
 		}
+		eventCounter.WithLabelValues(eventType, eventStatusProcessed).Inc()
 	}
 }
 
-func (ob *Observer) processExecutionTask(eventLog model.EventLog) error {
+// processEvent marks the event processed and calls the registered handler in the same store transaction
+func (ob *Observer) processEvent(eventLog model.EventLog) error {
+	registration, ok := ob.Registry.Get(eventLog.EventName)
+	if !ok {
+		return fmt.Errorf("event type not registered")
+	}
+
+	return ob.Store.Transaction(func(tx store.Store) error {
+		if err := tx.MarkEventProcessed(&eventLog); err != nil {
+			return err
+		}
+		if registration.Handler != nil {
+			return registration.Handler(tx, &eventLog)
+		}
+		return nil
+	})
+}
+
+// handleExecutionTask creates the execution task of the invocation for the executor, the task rejected
+// by the admission policy is recorded with the reason
+func (ob *Observer) handleExecutionTask(tx store.Store, eventLog *model.EventLog) error {
+	rejectReason, err := ob.admitExecutionTask(eventLog)
+	if err != nil {
+		return err
+	}
+
 	taskModel := &model.ExecutionTask{
 		InvokeTxHash:      eventLog.TxHash,
 		TaskId:            eventLog.TaskId,
+		Operator:          eventLog.Operator,
 		ExecutionObjectId: eventLog.ExecutableObjectId,
 		ExecutionUri:      "", // todo
 		InputFiles:        eventLog.InputObjectIds,
@@ -192,64 +540,37 @@ func (ob *Observer) processExecutionTask(eventLog model.EventLog) error {
 		Status:            model.ExecutionTaskStatusStatusInit,
 	}
 
-	tx := ob.DB.Begin()
-	if err := tx.Error; err != nil {
-		util.Logger.Errorf("start transaction error, err=%s", err.Error())
-		return err
-	}
-
-	err := tx.Model(&eventLog).Updates(
-		map[string]interface{}{
-			"status":      model.EventStatusProcessed,
-			"update_time": time.Now().Unix(),
-		}).Error
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	if err := tx.Create(taskModel).Error; err != nil {
-		tx.Rollback()
-		return err
+	if rejectReason != "" {
+		util.Logger.Infof("execution task rejected, task_id=%d, reason=%s", eventLog.TaskId, rejectReason)
+		taskModel.RejectReason = rejectReason
+		taskModel.Status = model.ExecutionTaskStatusStatusRejected
+		if ob.Config.AdmissionConfig.SubmitRejection {
+			// the sender submits the failed result for it
+			taskModel.Status = model.ExecutionTaskStatusStatusExecuted
+			taskModel.ResultStatus = model.ExecutionResultStatusRejected
+			taskModel.ExecutionStatus = rejectReason
+		}
 	}
 
-	err = tx.Commit().Error
-	if err != nil {
-		util.Logger.Errorf("commit transaction error, err=%s", err.Error())
-		return err
-	}
-	return nil
+	return tx.CreateTask(taskModel)
 }
 
-// SaveBlockAndEvents saves block and packages to database
-func (ob *Observer) SaveBlockAndEvents(blockLog *model.BlockLog, packages []interface{}) error {
-	tx := ob.DB.Begin()
-	if err := tx.Error; err != nil {
-		return err
-	}
-
-	if err := tx.Create(blockLog).Error; err != nil {
-		tx.Rollback()
+// SaveBlockAndEvents saves block and events to the store
+func (ob *Observer) SaveBlockAndEvents(blockLog *model.BlockLog, eventLogs []*model.EventLog) error {
+	if err := ob.Store.SaveBlockAndEvents(blockLog, eventLogs); err != nil {
 		return err
 	}
-
-	for _, pack := range packages {
-		if err := tx.Create(pack).Error; err != nil {
-			tx.Rollback()
-			return err
-		}
+	for _, eventLog := range eventLogs {
+		eventCounter.WithLabelValues(eventLog.EventName, eventStatusObserved).Inc()
 	}
-	return tx.Commit().Error
+	ob.reportHeight(blockLog.Height)
+	ob.syncHeartbeat.Beat()
+	return nil
 }
 
 // GetCurrentBlockLog returns the highest block log
 func (ob *Observer) GetCurrentBlockLog() (*model.BlockLog, error) {
-	blockLog := model.BlockLog{}
-	err := ob.DB.Order("height desc").Take(&blockLog).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return nil, err
-	}
-	return &blockLog, nil
+	return ob.Store.GetCurrentBlockLog()
 }
 
 // Alert sends alerts to tg group if there is no new block fetched in a specific time
@@ -0,0 +1,357 @@
+package observer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/archive"
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/event"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// fakeBlockSource is an in-memory chain, blocks of a branch have hashes like "a-10"
+type fakeBlockSource struct {
+	blocks map[int64]*common.BlockAndEventLogs
+}
+
+func newFakeBlockSource() *fakeBlockSource {
+	return &fakeBlockSource{blocks: make(map[int64]*common.BlockAndEventLogs)}
+}
+
+func (f *fakeBlockSource) GetLatestHeight() (int64, error) {
+	var latestHeight int64
+	for height := range f.blocks {
+		if height > latestHeight {
+			latestHeight = height
+		}
+	}
+	return latestHeight, nil
+}
+
+func (f *fakeBlockSource) GetBlockAndEventsAtHeight(height int64) (*common.BlockAndEventLogs, error) {
+	block, ok := f.blocks[height]
+	if !ok {
+		return nil, fmt.Errorf("block not found, height=%d", height)
+	}
+
+	// the observer saves the events, so hand out copies
+	result := *block
+	result.Events = nil
+	for _, event := range block.Events {
+		eventLog := *event
+		result.Events = append(result.Events, &eventLog)
+	}
+	return &result, nil
+}
+
+// extend replaces the blocks in [from, to] with the blocks of the given branch, taskIds maps
+// heights to the ids of the tasks invoked in the blocks
+func (f *fakeBlockSource) extend(from, to int64, branch string, taskIds map[int64]int64) {
+	for height := from; height <= to; height++ {
+		block := &common.BlockAndEventLogs{
+			Height:    height,
+			BlockHash: fmt.Sprintf("%s-%d", branch, height),
+			BlockTime: height,
+		}
+		if parent, ok := f.blocks[height-1]; ok {
+			block.ParentBlockHash = parent.BlockHash
+		}
+		if taskId, ok := taskIds[height]; ok {
+			block.Events = append(block.Events, &model.EventLog{
+				EventName: common.ExecutionTaskEvent,
+				TaskId:    taskId,
+				BlockHash: block.BlockHash,
+				TxHash:    fmt.Sprintf("tx-%s", block.BlockHash),
+				Height:    height,
+			})
+		}
+		f.blocks[height] = block
+	}
+}
+
+func newTestObserver(t *testing.T, source BlockSource) *Observer {
+	db, err := gorm.Open(common.DBDialectSqlite3, filepath.Join(t.TempDir(), "observer.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, model.Migrate(db))
+
+	cfg := &util.ObserverConfig{
+		AlertConfig: &util.AlertConfig{Moniker: "test"},
+	}
+	return NewObserver(store.NewSQLStore(db), cfg, source, nil, event.NewRegistry())
+}
+
+// testDB returns the db of the observer for checking the rows saved
+func testDB(ob *Observer) *gorm.DB {
+	return ob.Store.(*store.SQLStore).DB
+}
+
+// syncTo fetches blocks the same way as Fetch until the local chain reaches the given height
+func syncTo(t *testing.T, ob *Observer, startHeight, height int64) {
+	for i := 0; i < 100; i++ {
+		curBlockLog, err := ob.GetCurrentBlockLog()
+		require.NoError(t, err)
+		if curBlockLog.Height == height {
+			return
+		}
+
+		nextHeight := curBlockLog.Height + 1
+		if curBlockLog.Height == 0 {
+			nextHeight = startHeight
+		}
+		require.NoError(t, ob.fetchBlock(curBlockLog.Height, nextHeight, curBlockLog.BlockHash))
+	}
+	t.Fatalf("can not sync to height %d", height)
+}
+
+func processTaskEvents(t *testing.T, ob *Observer) {
+	var eventLogs []model.EventLog
+	require.NoError(t, testDB(ob).Where("event_name = ? and status != ?", common.ExecutionTaskEvent, model.EventStatusProcessed).Find(&eventLogs).Error)
+	for _, eventLog := range eventLogs {
+		require.NoError(t, ob.processEvent(eventLog))
+	}
+}
+
+func TestRollbackToCommonAncestor(t *testing.T) {
+	source := newFakeBlockSource()
+	source.extend(1, 10, "a", map[int64]int64{8: 1, 9: 2})
+
+	ob := newTestObserver(t, source)
+	syncTo(t, ob, 1, 10)
+	processTaskEvents(t, ob)
+
+	// task 2 has been executed, it can not be reverted
+	require.NoError(t, testDB(ob).Model(model.ExecutionTask{}).Where("task_id = ?", 2).
+		Update("status", model.ExecutionTaskStatusStatusExecuted).Error)
+
+	// blocks after 6 are replaced by another branch
+	source.extend(7, 12, "b", map[int64]int64{11: 3})
+
+	curBlockLog, err := ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.NoError(t, ob.fetchBlock(curBlockLog.Height, curBlockLog.Height+1, curBlockLog.BlockHash))
+
+	curBlockLog, err = ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.Equal(t, int64(6), curBlockLog.Height)
+	require.Equal(t, "a-6", curBlockLog.BlockHash)
+
+	var eventCount int
+	require.NoError(t, testDB(ob).Model(model.EventLog{}).Where("height > ?", 6).Count(&eventCount).Error)
+	require.Equal(t, 0, eventCount)
+
+	var tasks []model.ExecutionTask
+	require.NoError(t, testDB(ob).Order("task_id asc").Find(&tasks).Error)
+	require.Len(t, tasks, 1)
+	require.Equal(t, int64(2), tasks[0].TaskId)
+
+	syncTo(t, ob, 1, 12)
+	curBlockLog, err = ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.Equal(t, "b-12", curBlockLog.BlockHash)
+
+	var eventLogs []model.EventLog
+	require.NoError(t, testDB(ob).Where("event_name = ?", common.ExecutionTaskEvent).Find(&eventLogs).Error)
+	require.Len(t, eventLogs, 1)
+	require.Equal(t, int64(3), eventLogs[0].TaskId)
+	require.Equal(t, "b-11", eventLogs[0].BlockHash)
+}
+
+func TestRollbackBeyondLocalHistory(t *testing.T) {
+	source := newFakeBlockSource()
+	source.extend(1, 8, "a", nil)
+
+	ob := newTestObserver(t, source)
+	syncTo(t, ob, 5, 8)
+
+	// the fork point is before the start height, only the stored blocks can be reverted
+	source.extend(3, 9, "b", nil)
+
+	curBlockLog, err := ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.NoError(t, ob.fetchBlock(curBlockLog.Height, curBlockLog.Height+1, curBlockLog.BlockHash))
+
+	var blockCount int
+	require.NoError(t, testDB(ob).Model(model.BlockLog{}).Count(&blockCount).Error)
+	require.Equal(t, 0, blockCount)
+
+	syncTo(t, ob, 5, 9)
+	curBlockLog, err = ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.Equal(t, "b-9", curBlockLog.BlockHash)
+}
+
+func TestUpdateConfirmedNum(t *testing.T) {
+	for _, confirmNum := range []int64{0, 1, 3} {
+		source := newFakeBlockSource()
+		source.extend(1, 5, "a", map[int64]int64{2: 1, 4: 2})
+
+		ob := newTestObserver(t, source)
+		ob.Config.ConfirmNum = &confirmNum
+		syncTo(t, ob, 1, 5)
+
+		var confirmedTaskIds []int64
+		require.NoError(t, testDB(ob).Model(model.EventLog{}).Where("status = ?", model.EventStatusConfirmed).
+			Order("task_id asc").Pluck("task_id", &confirmedTaskIds).Error)
+
+		// the event at height 4 has 2 confirmations at height 5
+		if confirmNum <= 2 {
+			require.Equal(t, []int64{1, 2}, confirmedTaskIds, "confirmNum=%d", confirmNum)
+		} else {
+			require.Equal(t, []int64{1}, confirmedTaskIds, "confirmNum=%d", confirmNum)
+		}
+	}
+}
+
+func TestFetchInCatchUpMode(t *testing.T) {
+	source := newFakeBlockSource()
+	source.extend(1, 10, "a", map[int64]int64{3: 1, 9: 2})
+
+	ob := newTestObserver(t, source)
+	ob.Config.CatchUpWindow = 4
+
+	fetchNext := func() {
+		curBlockLog, err := ob.GetCurrentBlockLog()
+		require.NoError(t, err)
+		require.NoError(t, ob.fetchNext(curBlockLog, curBlockLog.Height+1))
+	}
+
+	// 1-4, 5-8, 9-10
+	for i := 0; i < 3; i++ {
+		fetchNext()
+	}
+	curBlockLog, err := ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.Equal(t, "a-10", curBlockLog.BlockHash)
+
+	var eventCount int
+	require.NoError(t, testDB(ob).Model(model.EventLog{}).Count(&eventCount).Error)
+	require.Equal(t, 2, eventCount)
+
+	// the fork is detected at the start of the window
+	source.extend(8, 20, "b", nil)
+	fetchNext()
+	curBlockLog, err = ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.Equal(t, "a-7", curBlockLog.BlockHash)
+
+	for i := 0; i < 4; i++ {
+		fetchNext()
+	}
+	curBlockLog, err = ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.Equal(t, "b-20", curBlockLog.BlockHash)
+
+	// tip-following once caught up
+	source.extend(21, 21, "b", nil)
+	fetchNext()
+	curBlockLog, err = ob.GetCurrentBlockLog()
+	require.NoError(t, err)
+	require.Equal(t, "b-21", curBlockLog.BlockHash)
+}
+
+func TestReceiveHeights(t *testing.T) {
+	ob := newTestObserver(t, newFakeBlockSource())
+
+	heights := make(chan int64, 3)
+	heights <- 5
+	heights <- 6
+	heights <- 9
+	close(heights)
+
+	require.Equal(t, int64(9), ob.receiveHeights(heights, 4))
+	ob.waitForNewBlock()
+	require.Equal(t, int64(5), ob.latestHeight)
+}
+
+func TestPruneEventLogsWithArchival(t *testing.T) {
+	source := newFakeBlockSource()
+	source.extend(1, 5, "a", map[int64]int64{1: 1, 2: 2, 3: 3, 4: 4})
+
+	ob := newTestObserver(t, source)
+	archiveDir := t.TempDir()
+	ob.Archiver = archive.NewFileArchiver(archiveDir)
+	ob.Config.PruneConfig = &util.PruneConfig{BatchSize: 2}
+	syncTo(t, ob, 1, 5)
+	processTaskEvents(t, ob)
+
+	// events of task 1-3 are outdated
+	require.NoError(t, testDB(ob).Model(model.EventLog{}).Where("task_id <= ?", 3).
+		Update("update_time", time.Now().Unix()-100).Error)
+
+	policy := util.RetentionPolicy{Status: int(model.EventStatusProcessed), Retention: 50}
+	require.NoError(t, ob.pruneEventLogs(policy))
+
+	var taskIds []int64
+	require.NoError(t, testDB(ob).Model(model.EventLog{}).Pluck("task_id", &taskIds).Error)
+	require.Equal(t, []int64{4}, taskIds)
+
+	// tasks are kept
+	var taskCount int
+	require.NoError(t, testDB(ob).Model(model.ExecutionTask{}).Count(&taskCount).Error)
+	require.Equal(t, 4, taskCount)
+
+	files, err := filepath.Glob(filepath.Join(archiveDir, "event_log_status2_*.jsonl.gz"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	var lines int
+	for _, file := range files {
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		zr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(zr)
+		for scanner.Scan() {
+			lines++
+		}
+		f.Close()
+	}
+	require.Equal(t, 3, lines)
+}
+
+func TestProcessRegisteredEvent(t *testing.T) {
+	ob := newTestObserver(t, newFakeBlockSource())
+
+	const deleteBucketEvent = "greenfield.storage.EventDeleteBucket"
+	var handled []int64
+	ob.Registry.Register(deleteBucketEvent, event.Registration{
+		Handler: func(tx store.Store, eventLog *model.EventLog) error {
+			handled = append(handled, eventLog.Height)
+			return nil
+		},
+	})
+	require.Equal(t, []string{common.ExecutionTaskEvent, common.ExecutionResultEvent, deleteBucketEvent}, ob.Registry.EventTypes())
+
+	eventLog := model.EventLog{EventName: deleteBucketEvent, Height: 7, Status: model.EventStatusConfirmed}
+	require.NoError(t, testDB(ob).Create(&eventLog).Error)
+	require.NoError(t, ob.processEvent(eventLog))
+	require.Equal(t, []int64{7}, handled)
+
+	require.NoError(t, testDB(ob).First(&eventLog, eventLog.Id).Error)
+	require.Equal(t, model.EventStatusProcessed, eventLog.Status)
+
+	// the event stays confirmed if the handler fails
+	ob.Registry.Register(deleteBucketEvent, event.Registration{
+		Handler: func(tx store.Store, eventLog *model.EventLog) error {
+			return fmt.Errorf("handle error")
+		},
+	})
+	eventLog = model.EventLog{EventName: deleteBucketEvent, Height: 8, Status: model.EventStatusConfirmed}
+	require.NoError(t, testDB(ob).Create(&eventLog).Error)
+	require.Error(t, ob.processEvent(eventLog))
+	require.NoError(t, testDB(ob).First(&eventLog, eventLog.Id).Error)
+	require.Equal(t, model.EventStatusConfirmed, eventLog.Status)
+}
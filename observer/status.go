@@ -0,0 +1,30 @@
+package observer
+
+// SyncStatus is how far the observer is synced with greenfield
+type SyncStatus struct {
+	Height    int64  `json:"height"`     // the highest block saved
+	BlockTime int64  `json:"block_time"` // time of the highest block saved
+	ChainTip  int64  `json:"chain_tip"`  // the latest height of greenfield, 0 if it fails to be queried
+	Lag       int64  `json:"lag"`        // the blocks behind the chain tip
+	Error     string `json:"error,omitempty"`
+}
+
+// SyncStatus returns the sync status of the observer, the chain tip is queried from the block source
+func (ob *Observer) SyncStatus() (*SyncStatus, error) {
+	blockLog, err := ob.GetCurrentBlockLog()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SyncStatus{Height: blockLog.Height, BlockTime: blockLog.BlockTime}
+	latestHeight, err := ob.Client.GetLatestHeight()
+	if err != nil {
+		status.Error = err.Error()
+		return status, nil
+	}
+	status.ChainTip = latestHeight
+	if latestHeight > blockLog.Height {
+		status.Lag = latestHeight - blockLog.Height
+	}
+	return status, nil
+}
@@ -0,0 +1,19 @@
+package observer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncStatus(t *testing.T) {
+	source := newFakeBlockSource()
+	source.extend(1, 10, "a", nil)
+
+	ob := newTestObserver(t, source)
+	syncTo(t, ob, 1, 6)
+
+	status, err := ob.SyncStatus()
+	require.NoError(t, err)
+	require.Equal(t, &SyncStatus{Height: 6, BlockTime: 6, ChainTip: 10, Lag: 4}, status)
+}
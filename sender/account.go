@@ -0,0 +1,129 @@
+package sender
+
+import (
+	"context"
+	"sync"
+
+	sdkclient "github.com/bnb-chain/greenfield-go-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"google.golang.org/grpc"
+
+	"github.com/bnb-chain/greenfield-execution-provider/client"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+	"github.com/bnb-chain/greenfield/sdk/types"
+)
+
+// TxClient is the part of the sdk client simulating and broadcasting the receipt txs
+type TxClient interface {
+	SimulateTx(ctx context.Context, msgs []sdk.Msg, txOpt types.TxOption, opts ...grpc.CallOption) (*tx.SimulateResponse, error)
+	BroadcastTx(ctx context.Context, msgs []sdk.Msg, txOpt types.TxOption, opts ...grpc.CallOption) (*tx.BroadcastTxResponse, error)
+}
+
+// Account is an account submitting execution results. its sequence is tracked locally, so several txs
+// of it can be in flight at the same time. the txs are pinned to the endpoint the sequence is fetched from,
+// as another node may not have seen the txs in flight and rejects the following ones
+type Account struct {
+	Address string
+	clients *client.SDKClients
+
+	mtx      sync.Mutex
+	sequence uint64
+	synced   bool
+	endpoint string // the endpoint the txs are broadcast to, pinned when the sequence is synced
+
+	bestEndpoint    func() string
+	endpointHealthy func(addr string) bool
+	fetchSequence   func(ctx context.Context, endpoint string) (uint64, error)
+	txClient        func(endpoint string) TxClient
+	queryResult     func(ctx context.Context, taskId int64) (*client.ExecutionResult, error)
+}
+
+func NewAccount(clients *client.SDKClients) (*Account, error) {
+	defaultAccount, err := clients.Client().GetDefaultAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	account := &Account{
+		Address:         defaultAccount.GetAddress().String(),
+		clients:         clients,
+		bestEndpoint:    clients.Best,
+		endpointHealthy: clients.Healthy,
+		txClient: func(endpoint string) TxClient {
+			return clients.ClientOf(endpoint)
+		},
+		queryResult: clients.GetExecutionResult,
+	}
+	account.fetchSequence = func(ctx context.Context, endpoint string) (uint64, error) {
+		acc, err := clients.ClientOf(endpoint).GetAccount(ctx, account.Address)
+		if err != nil {
+			return 0, err
+		}
+		return acc.GetSequence(), nil
+	}
+	return account, nil
+}
+
+// Client returns the sdk client of the healthiest endpoint for the queries
+func (a *Account) Client() sdkclient.Client {
+	return a.clients.Client()
+}
+
+// TxClient returns the client broadcasting the txs to the given endpoint
+func (a *Account) TxClient(endpoint string) TxClient {
+	return a.txClient(endpoint)
+}
+
+// GetExecutionResult returns the execution result of the task on chain, nil if no result is submitted
+func (a *Account) GetExecutionResult(ctx context.Context, taskId int64) (*client.ExecutionResult, error) {
+	return a.queryResult(ctx, taskId)
+}
+
+// NextSequence returns the sequence of the next tx and the endpoint to broadcast it to. the sequence is fetched
+// from chain if it is not synced, or the endpoint pinned fails over to another one
+func (a *Account) NextSequence(ctx context.Context) (uint64, string, error) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if a.synced && !a.endpointHealthy(a.endpoint) {
+		util.Logger.Infof("rpc endpoint of account is unhealthy, resync the sequence, account=%s, endpoint=%s", a.Address, a.endpoint)
+		a.synced = false
+	}
+	if !a.synced {
+		endpoint := a.bestEndpoint()
+		sequence, err := a.fetchSequence(ctx, endpoint)
+		if err != nil {
+			return 0, "", err
+		}
+		a.sequence = sequence
+		a.endpoint = endpoint
+		a.synced = true
+	}
+
+	sequence := a.sequence
+	a.sequence++
+	return sequence, a.endpoint, nil
+}
+
+// Release returns the sequence of a tx not broadcast, so the next tx takes it. the sequence is fetched from chain
+// again if other txs took the sequences following it
+func (a *Account) Release(sequence uint64) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if a.synced && a.sequence == sequence+1 {
+		a.sequence = sequence
+		return
+	}
+	a.synced = false
+}
+
+// Resync makes the sequence fetched from chain again, it is called when a tx may not take its sequence,
+// e.g. it is rejected by check tx or dropped from the mempool
+func (a *Account) Resync() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.synced = false
+}
@@ -0,0 +1,100 @@
+package sender
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAccount returns an account on the endpoints given, the first healthy endpoint is the best one
+func newTestAccount(sequences map[string]uint64, healthy map[string]bool, endpoints ...string) *Account {
+	return &Account{
+		Address: "a",
+		bestEndpoint: func() string {
+			for _, endpoint := range endpoints {
+				if healthy[endpoint] {
+					return endpoint
+				}
+			}
+			return endpoints[0]
+		},
+		endpointHealthy: func(addr string) bool {
+			return healthy[addr]
+		},
+		fetchSequence: func(ctx context.Context, endpoint string) (uint64, error) {
+			return sequences[endpoint], nil
+		},
+	}
+}
+
+func TestAccountSequence(t *testing.T) {
+	sequences := map[string]uint64{"e1": 5}
+	account := newTestAccount(sequences, map[string]bool{"e1": true}, "e1")
+
+	for _, expected := range []uint64{5, 6, 7} {
+		sequence, endpoint, err := account.NextSequence(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expected, sequence)
+		require.Equal(t, "e1", endpoint)
+	}
+
+	// the tx of sequence 7 is rejected
+	sequences["e1"] = 7
+	account.Resync()
+	sequence, _, err := account.NextSequence(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), sequence)
+}
+
+func TestAccountEndpointFailover(t *testing.T) {
+	sequences := map[string]uint64{"e1": 5, "e2": 3}
+	healthy := map[string]bool{"e1": true, "e2": true}
+	account := newTestAccount(sequences, healthy, "e1", "e2")
+
+	sequence, endpoint, err := account.NextSequence(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), sequence)
+	require.Equal(t, "e1", endpoint)
+
+	// e2 becomes the best endpoint, the txs in flight keep the account pinned to e1
+	sequences["e2"] = 6
+	account.bestEndpoint = func() string { return "e2" }
+	sequence, endpoint, err = account.NextSequence(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), sequence)
+	require.Equal(t, "e1", endpoint)
+
+	// e1 fails over, the sequence is fetched from e2 which the account is pinned to then
+	healthy["e1"] = false
+	sequence, endpoint, err = account.NextSequence(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), sequence)
+	require.Equal(t, "e2", endpoint)
+
+	sequence, endpoint, err = account.NextSequence(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), sequence)
+	require.Equal(t, "e2", endpoint)
+}
+
+func TestAccountRelease(t *testing.T) {
+	sequences := map[string]uint64{"e1": 5}
+	account := newTestAccount(sequences, map[string]bool{"e1": true}, "e1")
+
+	sequence, _, err := account.NextSequence(context.Background())
+	require.NoError(t, err)
+	account.Release(sequence)
+	sequence, _, err = account.NextSequence(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), sequence)
+
+	// sequence 6 is taken after 5, releasing 5 resyncs the sequence
+	_, _, err = account.NextSequence(context.Background())
+	require.NoError(t, err)
+	account.Release(sequence)
+	sequences["e1"] = 5
+	sequence, _, err = account.NextSequence(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), sequence)
+}
@@ -0,0 +1,106 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+	"github.com/bnb-chain/greenfield/sdk/types"
+)
+
+// simulateError is the failure to simulate the leading msgs of a batch, the error may name the msg failing it
+type simulateError struct {
+	count int // the number of leading msgs simulated
+	err   error
+}
+
+func (e *simulateError) Error() string {
+	return e.err.Error()
+}
+
+func (e *simulateError) Unwrap() error {
+	return e.err
+}
+
+// txOption returns the tx option of the sequence with the gas limit and the fee decided by the fee config, and the
+// number of leading msgs fitting in the gas bound of a batch
+func (s *Sender) txOption(ctx context.Context, cli TxClient, msgs []sdk.Msg, sequence uint64) (types.TxOption, int, error) {
+	feeConfig := s.Config.GetFeeConfig()
+
+	count := len(msgs)
+	gasLimit := s.Config.GreenfieldConfig.GasLimit
+	fee := sdk.NewCoin(types.Denom, sdkmath.NewIntFromUint64(s.Config.GreenfieldConfig.FeeAmount))
+	if feeConfig.Simulate || gasLimit == 0 || fee.IsZero() {
+		for {
+			res, err := cli.SimulateTx(ctx, msgs[:count], types.TxOption{Nonce: sequence})
+			if err != nil {
+				return types.TxOption{}, 0, &simulateError{count: count, err: err}
+			}
+			gasLimit, fee, err = estimateFee(res.GasInfo.GetGasUsed(), res.GasInfo.GetMinGasPrice(), feeConfig.GetGasMultiplier())
+			if err != nil {
+				return types.TxOption{}, 0, err
+			}
+			if count == 1 || s.Config.MaxBatchGas == 0 || gasLimit <= s.Config.MaxBatchGas {
+				break
+			}
+			count /= 2
+		}
+	} else {
+		count = batchCount(count, gasLimit, s.Config.MaxBatchGas)
+		gasLimit *= uint64(count)
+		fee.Amount = fee.Amount.MulRaw(int64(count))
+	}
+
+	if err := checkFeeCap(fee, count, feeConfig); err != nil {
+		return types.TxOption{}, 0, err
+	}
+	return types.TxOption{
+		Nonce:      sequence,
+		NoSimulate: true,
+		GasLimit:   gasLimit,
+		FeeAmount:  sdk.NewCoins(fee),
+	}, count, nil
+}
+
+// batchCount returns the number of msgs of the gas limit each fitting in the gas bound, at least 1
+func batchCount(count int, gasLimit uint64, maxBatchGas uint64) int {
+	if maxBatchGas == 0 || gasLimit == 0 {
+		return count
+	}
+	if maxCount := maxBatchGas / gasLimit; uint64(count) > maxCount {
+		count = int(maxCount)
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
+
+// estimateFee returns the gas limit of the simulated gas raised by the multiplier, and the fee of it
+func estimateFee(gasUsed uint64, minGasPrice string, multiplier float64) (uint64, sdk.Coin, error) {
+	gasPrice, err := sdk.ParseCoinNormalized(minGasPrice)
+	if err != nil {
+		return 0, sdk.Coin{}, err
+	}
+	if gasPrice.IsNil() || gasPrice.IsZero() {
+		return 0, sdk.Coin{}, fmt.Errorf("invalid simulated gas price %s", minGasPrice)
+	}
+
+	gasLimit := uint64(math.Ceil(float64(gasUsed) * multiplier))
+	return gasLimit, sdk.NewCoin(gasPrice.Denom, gasPrice.Amount.Mul(sdkmath.NewIntFromUint64(gasLimit))), nil
+}
+
+// checkFeeCap returns an error if the fee per msg exceeds the cap
+func checkFeeCap(fee sdk.Coin, count int, feeConfig *util.FeeConfig) error {
+	if feeConfig.MaxFeeAmount == 0 {
+		return nil
+	}
+	if fee.Amount.GT(sdkmath.NewIntFromUint64(feeConfig.MaxFeeAmount).MulRaw(int64(count))) {
+		return fmt.Errorf("fee %s of %d results exceeds the cap %d per result", fee.String(), count, feeConfig.MaxFeeAmount)
+	}
+	return nil
+}
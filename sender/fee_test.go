@@ -0,0 +1,34 @@
+package sender
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+func TestEstimateFee(t *testing.T) {
+	gasLimit, fee, err := estimateFee(1000, "5000000000BNB", 1.5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1500), gasLimit)
+	require.Equal(t, sdk.NewCoin("BNB", sdkmath.NewInt(7500000000000)), fee)
+
+	_, _, err = estimateFee(1000, "0BNB", 1)
+	require.Error(t, err)
+
+	fee = sdk.NewCoin("BNB", sdkmath.NewInt(100))
+	require.NoError(t, checkFeeCap(fee, 1, &util.FeeConfig{}))
+	require.NoError(t, checkFeeCap(fee, 1, &util.FeeConfig{MaxFeeAmount: 100}))
+	require.Error(t, checkFeeCap(fee, 1, &util.FeeConfig{MaxFeeAmount: 99}))
+	require.NoError(t, checkFeeCap(fee, 2, &util.FeeConfig{MaxFeeAmount: 50}))
+}
+
+func TestBatchCount(t *testing.T) {
+	require.Equal(t, 10, batchCount(10, 30000, 0))
+	require.Equal(t, 3, batchCount(10, 30000, 100000))
+	require.Equal(t, 2, batchCount(2, 30000, 100000))
+	require.Equal(t, 1, batchCount(10, 30000, 10000))
+}
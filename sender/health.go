@@ -0,0 +1,34 @@
+package sender
+
+import (
+	"fmt"
+
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+// LivenessChecks returns the heartbeats of the send and confirm routines
+func (s *Sender) LivenessChecks() map[string]util.HealthCheck {
+	return map[string]util.HealthCheck{
+		"send":    s.sendHeartbeat.Check,
+		"confirm": s.confirmHeartbeat.Check,
+	}
+}
+
+// ReadinessChecks returns whether the rpc endpoints of the accounts are reachable
+func (s *Sender) ReadinessChecks() map[string]util.HealthCheck {
+	return map[string]util.HealthCheck{
+		"rpc": s.rpcReachable,
+	}
+}
+
+func (s *Sender) rpcReachable() error {
+	for _, account := range s.accounts {
+		if account.clients == nil {
+			continue
+		}
+		if err := account.clients.Reachable(); err != nil {
+			return fmt.Errorf("account=%s, %s", account.Address, err.Error())
+		}
+	}
+	return nil
+}
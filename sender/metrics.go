@@ -0,0 +1,87 @@
+package sender
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+)
+
+var (
+	submissionCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "sender",
+		Name:      "submissions_total",
+		Help:      "The receipt txs accepted by the mempool of each account.",
+	}, []string{"account"})
+	submittedResultCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "sender",
+		Name:      "submitted_results_total",
+		Help:      "The results in the receipt txs accepted by the mempool of each account.",
+	}, []string{"account"})
+	includedResultCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "sender",
+		Name:      "included_results_total",
+		Help:      "The results whose receipt txs are included.",
+	})
+	failureCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "sender",
+		Name:      "failures_total",
+		Help:      "The failed submissions of results, resubmitted or dead-lettered.",
+	}, []string{"outcome"})
+	feeCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "sender",
+		Name:      "fees_paid_total",
+		Help:      "The fees of the receipt txs accepted by the mempool, in the smallest unit of the denom.",
+	}, []string{"denom"})
+	pendingGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "sender",
+		Name:      "pending_results",
+		Help:      "The results waiting for submission or inclusion.",
+	}, []string{"state"})
+	inflightTxsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: "sender",
+		Name:      "inflight_txs",
+		Help:      "The receipt txs of each account waiting for inclusion.",
+	}, []string{"account"})
+)
+
+const (
+	failureOutcomeResubmit   = "resubmit"
+	failureOutcomeDeadLetter = "dead_letter"
+
+	pendingStateToSubmit = "to_submit"
+	pendingStateInflight = "inflight"
+)
+
+// reportPending reports the results waiting for submission and inclusion periodically
+func (s *Sender) reportPending() {
+	for {
+		time.Sleep(common.SenderReportInterval)
+
+		queue, err := s.PendingQueue()
+		if err != nil {
+			util.Logger.Errorf("get pending execution results error: %s", err.Error())
+			continue
+		}
+
+		inflight := 0
+		for _, tx := range queue.Inflight {
+			inflight += len(tx.TaskIds)
+		}
+		pendingGauge.WithLabelValues(pendingStateToSubmit).Set(float64(len(queue.ToSubmit)))
+		pendingGauge.WithLabelValues(pendingStateInflight).Set(float64(inflight))
+		for _, account := range queue.Accounts {
+			inflightTxsGauge.WithLabelValues(account.Address).Set(float64(account.InflightTxs))
+		}
+	}
+}
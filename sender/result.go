@@ -0,0 +1,91 @@
+package sender
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+)
+
+const (
+	resultGasUsedKey     = "gas_used"
+	resultLogDataUriKey  = "log_data_uri"
+	resultAttestationKey = "attestation_uri"
+	resultVersionKey     = "v"
+	resultDataUriDivider = "?"
+
+	// ResultDataUriVersion is the version of the format of the result data uri, it is raised on incompatible changes
+	ResultDataUriVersion = "1"
+)
+
+// ExecutionResult is the execution result submitted on chain
+type ExecutionResult struct {
+	Status         model.ExecutionResultStatus
+	ResultDataUri  string // object id of the result data
+	LogDataUri     string // object id of the logs
+	AttestationUri string // object id of the attestation document, see attestation.Decode
+	GasUsed        int64
+}
+
+// newExecutionResult returns the execution result of the executed task
+func newExecutionResult(task *model.ExecutionTask) ExecutionResult {
+	return ExecutionResult{
+		Status:         task.ResultStatus,
+		ResultDataUri:  task.ResultDataUri,
+		LogDataUri:     task.LogDataUri,
+		AttestationUri: task.AttestationUri,
+		GasUsed:        task.GasUsed,
+	}
+}
+
+// EncodeUri returns the result data uri submitted on chain. the message only carries the status and one uri,
+// so the gas used, the log uri and the attestation uri are appended to the result data uri as the query along
+// with the format version, e.g. "123?attestation_uri=125&gas_used=100&log_data_uri=124&v=1". the format is a
+// public contract documented in the readme, ParseExecutionResult is the reference parser
+func (r ExecutionResult) EncodeUri() string {
+	query := url.Values{}
+	query.Set(resultVersionKey, ResultDataUriVersion)
+	query.Set(resultGasUsedKey, strconv.FormatInt(r.GasUsed, 10))
+	if r.LogDataUri != "" {
+		query.Set(resultLogDataUriKey, r.LogDataUri)
+	}
+	if r.AttestationUri != "" {
+		query.Set(resultAttestationKey, r.AttestationUri)
+	}
+	return r.ResultDataUri + resultDataUriDivider + query.Encode()
+}
+
+// ParseExecutionResult parses the status and the result data uri submitted on chain. a uri without the query is
+// a plain object id of the result data, a uri of an unknown version is rejected
+func ParseExecutionResult(status uint32, resultDataUri string) (ExecutionResult, error) {
+	result := ExecutionResult{
+		Status:        model.ExecutionResultStatus(status),
+		ResultDataUri: resultDataUri,
+	}
+
+	idx := strings.Index(resultDataUri, resultDataUriDivider)
+	if idx < 0 {
+		return result, nil
+	}
+	result.ResultDataUri = resultDataUri[:idx]
+
+	query, err := url.ParseQuery(resultDataUri[idx+1:])
+	if err != nil {
+		return result, err
+	}
+	// the uris submitted before the version was added are of version 1
+	if version := query.Get(resultVersionKey); version != "" && version != ResultDataUriVersion {
+		return result, fmt.Errorf("unsupported result data uri version %q", version)
+	}
+	if gasUsed := query.Get(resultGasUsedKey); gasUsed != "" {
+		result.GasUsed, err = strconv.ParseInt(gasUsed, 10, 64)
+		if err != nil {
+			return result, err
+		}
+	}
+	result.LogDataUri = query.Get(resultLogDataUriKey)
+	result.AttestationUri = query.Get(resultAttestationKey)
+	return result, nil
+}
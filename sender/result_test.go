@@ -0,0 +1,69 @@
+package sender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+)
+
+func TestExecutionResult(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		task   model.ExecutionTask
+		status uint32
+		uri    string
+	}{
+		{
+			"success",
+			model.ExecutionTask{ResultStatus: model.ExecutionResultStatusSuccess, GasUsed: 100, ResultDataUri: "11", LogDataUri: "12", AttestationUri: "13"},
+			1, "11?attestation_uri=13&gas_used=100&log_data_uri=12&v=1",
+		},
+		{
+			"trap",
+			model.ExecutionTask{ResultStatus: model.ExecutionResultStatusTrap, GasUsed: 50, ResultDataUri: "21", LogDataUri: "22"},
+			2, "21?gas_used=50&log_data_uri=22&v=1",
+		},
+		{
+			"out of gas",
+			model.ExecutionTask{ResultStatus: model.ExecutionResultStatusOutOfGas, GasUsed: 1000, ResultDataUri: "31", LogDataUri: "32"},
+			3, "31?gas_used=1000&log_data_uri=32&v=1",
+		},
+		{
+			"timeout",
+			model.ExecutionTask{ResultStatus: model.ExecutionResultStatusTimeout, ResultDataUri: "41", LogDataUri: "42"},
+			4, "41?gas_used=0&log_data_uri=42&v=1",
+		},
+		{
+			"provider failure",
+			model.ExecutionTask{ResultStatus: model.ExecutionResultStatusProviderFailure, ResultDataUri: "51"},
+			0, "51?gas_used=0&v=1",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			result := newExecutionResult(&c.task)
+			require.Equal(t, c.status, uint32(result.Status))
+			require.Equal(t, c.uri, result.EncodeUri())
+
+			parsed, err := ParseExecutionResult(c.status, c.uri)
+			require.NoError(t, err)
+			require.Equal(t, result, parsed)
+		})
+	}
+}
+
+func TestParseExecutionResult(t *testing.T) {
+	// a plain object id
+	result, err := ParseExecutionResult(1, "61")
+	require.NoError(t, err)
+	require.Equal(t, ExecutionResult{Status: model.ExecutionResultStatusSuccess, ResultDataUri: "61"}, result)
+
+	_, err = ParseExecutionResult(1, "61?gas_used=100&v=2")
+	require.EqualError(t, err, `unsupported result data uri version "2"`)
+
+	// submitted before the version was added
+	result, err = ParseExecutionResult(1, "61?gas_used=100&log_data_uri=62")
+	require.NoError(t, err)
+	require.Equal(t, ExecutionResult{Status: model.ExecutionResultStatusSuccess, ResultDataUri: "61", LogDataUri: "62", GasUsed: 100}, result)
+}
@@ -0,0 +1,52 @@
+package sender
+
+import (
+	"time"
+
+	errorsmod "cosmossdk.io/errors"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+// permanentErrors are the errors of the receipt tx that fail again however many times it is resubmitted
+var permanentErrors = []*errorsmod.Error{
+	sdkerrors.ErrTxDecode,
+	sdkerrors.ErrUnauthorized,
+	sdkerrors.ErrUnknownRequest,
+	sdkerrors.ErrInvalidAddress,
+	sdkerrors.ErrInvalidRequest,
+	storagetypes.ErrInvalidTaskId,
+}
+
+// isRetryable returns whether the receipt tx failed with the code may succeed when resubmitted, e.g. it is out
+// of gas or the account sequence mismatches
+func isRetryable(codespace string, code uint32) bool {
+	for _, permanentErr := range permanentErrors {
+		if codespace == permanentErr.Codespace() && code == permanentErr.ABCICode() {
+			return false
+		}
+	}
+	return true
+}
+
+// isRetryableError returns whether the receipt tx failed to be broadcast with the error may succeed when
+// resubmitted, errors not registered by the chain, e.g. network errors, are retryable
+func isRetryableError(err error) bool {
+	codespace, code, _ := sdkerrors.ABCIInfo(err, false)
+	return isRetryable(codespace, code)
+}
+
+// backoff returns the interval to wait before resubmitting the result after the given attempts,
+// it doubles with each attempt up to common.SenderRetryMaxInterval
+func backoff(attempts int) time.Duration {
+	interval := common.SenderRetryBaseInterval
+	for i := 1; i < attempts && interval < common.SenderRetryMaxInterval; i++ {
+		interval *= 2
+	}
+	if interval > common.SenderRetryMaxInterval {
+		interval = common.SenderRetryMaxInterval
+	}
+	return interval
+}
@@ -0,0 +1,107 @@
+package sender
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	errorsmod "cosmossdk.io/errors"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+func TestIsRetryable(t *testing.T) {
+	require.True(t, isRetryable(sdkerrors.ErrOutOfGas.Codespace(), sdkerrors.ErrOutOfGas.ABCICode()))
+	require.True(t, isRetryable(sdkerrors.ErrWrongSequence.Codespace(), sdkerrors.ErrWrongSequence.ABCICode()))
+	require.False(t, isRetryable(storagetypes.ErrInvalidTaskId.Codespace(), storagetypes.ErrInvalidTaskId.ABCICode()))
+
+	require.True(t, isRetryableError(errors.New("connection refused")))
+	require.False(t, isRetryableError(errorsmod.Wrap(sdkerrors.ErrInvalidAddress, "invalid operator address")))
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, common.SenderRetryBaseInterval, backoff(1))
+	require.Equal(t, 2*common.SenderRetryBaseInterval, backoff(2))
+	require.Equal(t, 8*common.SenderRetryBaseInterval, backoff(4))
+	require.Equal(t, common.SenderRetryMaxInterval, backoff(100))
+}
+
+func TestFailSubmission(t *testing.T) {
+	s := newTestSender(t)
+	for _, taskId := range []int64{1, 2, 3} {
+		require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{TaskId: taskId, Status: model.ExecutionTaskStatusStatusExecuted}))
+	}
+
+	// the failed task backs off, and the queue continues with the others
+	resubmits := testutil.ToFloat64(failureCounter.WithLabelValues(failureOutcomeResubmit))
+	tasks, err := s.Store.NextResultsToSubmit(time.Now().Unix(), 1)
+	require.NoError(t, err)
+	require.Error(t, s.failSubmission(&Account{Address: "a"}, tasks, errors.New("connection refused")))
+	require.Equal(t, resubmits+1, testutil.ToFloat64(failureCounter.WithLabelValues(failureOutcomeResubmit)))
+	saved := getTask(t, s, 1)
+	require.Equal(t, model.ExecutionTaskStatusStatusExecuted, saved.Status)
+	require.Equal(t, 1, saved.SubmitAttempts)
+	require.Equal(t, "connection refused", saved.SubmitError)
+	require.GreaterOrEqual(t, saved.NextSubmitTime, time.Now().Add(common.SenderRetryBaseInterval).Unix()-1)
+
+	tasks, err = s.Store.NextResultsToSubmit(time.Now().Unix(), 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.Equal(t, int64(2), tasks[0].TaskId)
+
+	// a permanent failure is dead-lettered at once
+	deadLetters := testutil.ToFloat64(failureCounter.WithLabelValues(failureOutcomeDeadLetter))
+	require.Error(t, s.failSubmission(&Account{Address: "a"}, tasks[:1], errorsmod.Wrap(sdkerrors.ErrInvalidAddress, "invalid operator address")))
+	require.Equal(t, model.ExecutionTaskStatusStatusDeadLetter, getTask(t, s, 2).Status)
+	require.Equal(t, deadLetters+1, testutil.ToFloat64(failureCounter.WithLabelValues(failureOutcomeDeadLetter)))
+}
+
+func TestFailSimulation(t *testing.T) {
+	newTasks := func(s *Sender) []model.ExecutionTask {
+		for _, taskId := range []int64{1, 2, 3} {
+			require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{TaskId: taskId, Status: model.ExecutionTaskStatusStatusExecuted}))
+		}
+		tasks, err := s.Store.NextResultsToSubmit(time.Now().Unix(), 10)
+		require.NoError(t, err)
+		return tasks
+	}
+	attempts := func(s *Sender) []int {
+		var attempts []int
+		for _, taskId := range []int64{1, 2, 3} {
+			attempts = append(attempts, getTask(t, s, taskId).SubmitAttempts)
+		}
+		return attempts
+	}
+	noSimulate := func(idx int) error {
+		require.Fail(t, "simulated alone")
+		return nil
+	}
+
+	// only the msg named by the error takes the failure
+	s := newTestSender(t)
+	simErr := &simulateError{count: 3, err: errors.New("failed to execute message; message index: 1: execution result submitted")}
+	require.Error(t, s.failSimulation(&Account{Address: "a"}, 0, newTasks(s), simErr, noSimulate))
+	require.Equal(t, []int{0, 1, 0}, attempts(s))
+
+	// the msg failing alone takes the failure
+	s = newTestSender(t)
+	simErr = &simulateError{count: 3, err: errors.New("out of gas")}
+	require.Error(t, s.failSimulation(&Account{Address: "a"}, 0, newTasks(s), simErr, func(idx int) error {
+		if idx == 2 {
+			return errors.New("invalid task id")
+		}
+		return nil
+	}))
+	require.Equal(t, []int{0, 0, 1}, attempts(s))
+	require.Equal(t, "invalid task id", getTask(t, s, 3).SubmitError)
+
+	// all tasks take the failure if none fails alone
+	s = newTestSender(t)
+	require.Error(t, s.failSimulation(&Account{Address: "a"}, 0, newTasks(s), simErr, func(idx int) error { return nil }))
+	require.Equal(t, []int{1, 1, 1}, attempts(s))
+}
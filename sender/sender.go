@@ -2,67 +2,430 @@ package sender
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
 	"time"
 
 	"cosmossdk.io/math"
-	"github.com/jinzhu/gorm"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 
+	"github.com/bnb-chain/greenfield-execution-provider/client"
 	"github.com/bnb-chain/greenfield-execution-provider/common"
 	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
 	"github.com/bnb-chain/greenfield-execution-provider/util"
-	sdkclient "github.com/bnb-chain/greenfield-go-sdk/client"
-	"github.com/bnb-chain/greenfield/sdk/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
 )
 
+var failedMsgIndexRegexp = regexp.MustCompile(`message index: (\d+)`)
+
 type Sender struct {
-	DB        *gorm.DB
-	sdkClient sdkclient.Client
+	Store    store.Store
+	Config   *util.SenderConfig
+	accounts []*Account
+	next     int // index of the account to submit the next batch
+
+	sendHeartbeat    *util.Heartbeat // beaten on each round of the send routine
+	confirmHeartbeat *util.Heartbeat // beaten on each round of the confirm routine
 }
 
-func NewSender(db *gorm.DB, client sdkclient.Client) *Sender {
+func NewSender(st store.Store, cfg *util.SenderConfig, accounts []*Account) *Sender {
 	return &Sender{
-		DB:        db,
-		sdkClient: client,
+		Store:    st,
+		Config:   cfg,
+		accounts: accounts,
+
+		sendHeartbeat:    util.NewHeartbeat(common.HealthHeartbeatTimeout),
+		confirmHeartbeat: util.NewHeartbeat(common.HealthHeartbeatTimeout),
 	}
 }
 
 func (s *Sender) Start() {
 	go s.send()
+	go s.confirm()
+	go s.reportPending()
 }
 
 func (s *Sender) send() {
 	for {
 		time.Sleep(common.SenderSendInterval)
 
-		task, err := s.getResultToSubmit()
+		// the batches are pipelined until all results are submitted or all accounts are busy
+		for s.sendBatch() {
+			s.sendHeartbeat.Beat()
+		}
+		s.sendHeartbeat.Beat()
+	}
+}
+
+// sendBatch submits a batch of results by the next idle account, it returns whether a batch is submitted
+func (s *Sender) sendBatch() bool {
+	account, err := s.nextAccount()
+	if err != nil {
+		util.Logger.Errorf("get account to submit execution results error: %s", err.Error())
+		return false
+	}
+	if account == nil {
+		return false
+	}
+
+	tasks, err := s.Store.NextResultsToSubmit(time.Now().Unix(), s.Config.GetMaxBatchSize())
+	if err != nil || len(tasks) == 0 {
+		return false
+	}
+
+	if err := s.submit(account, tasks); err != nil {
+		util.Logger.Errorf("submit execution results error, account=%s, err=%s", account.Address, err.Error())
+		return false
+	}
+	return true
+}
+
+// reconcile marks the tasks having results on chain submitted, e.g. submitted by us before a crash or by another
+// provider, and returns the others. a task is submitted anyway if its result fails to be queried
+func (s *Sender) reconcile(account *Account, tasks []model.ExecutionTask) ([]model.ExecutionTask, error) {
+	var toSubmit []model.ExecutionTask
+	for idx := range tasks {
+		task := &tasks[idx]
+		result, err := account.GetExecutionResult(context.Background(), task.TaskId)
 		if err != nil {
+			util.Logger.Errorf("query execution result error, task_id=%d, err=%s", task.TaskId, err.Error())
+			toSubmit = append(toSubmit, *task)
+			continue
+		}
+		if result == nil {
+			toSubmit = append(toSubmit, *task)
 			continue
 		}
 
-		res, err := s.sdkClient.SubmitExecutionResult(context.Background(), math.NewUint(uint64(task.TaskId)), uint32(0 /*task.ExecutionStatus*/), task.ResultDataUri, types.TxOption{})
+		setSubmitter(task, result)
+		if err := s.updateTask(task, model.ExecutionTaskStatusStatusReceiptSubmitted); err != nil {
+			return nil, err
+		}
+	}
+	return toSubmit, nil
+}
+
+// nextAccount returns the next account round robin with less inflight txs than the limit, nil if all are busy
+func (s *Sender) nextAccount() (*Account, error) {
+	for i := 0; i < len(s.accounts); i++ {
+		account := s.accounts[s.next]
+		s.next = (s.next + 1) % len(s.accounts)
+
+		inflight, err := s.Store.InflightTxs(account.Address)
 		if err != nil {
-			util.Logger.Errorf("submit execution result error: %s", err.Error())
-			continue
+			return nil, err
+		}
+		if inflight < s.Config.GetMaxInflightTxs() {
+			return account, nil
 		}
+	}
+	return nil, nil
+}
 
-		util.Logger.Infof("submit execution result success, txHash=%s", res.TxHash)
+// submit broadcasts the results of tasks in one tx, the tasks not fitting in the gas bound are left to the next batch
+func (s *Sender) submit(account *Account, tasks []model.ExecutionTask) error {
+	tasks, err := s.reconcile(account, tasks)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	msgs := make([]sdk.Msg, 0, len(tasks))
+	for idx := range tasks {
+		result := newExecutionResult(&tasks[idx])
+		msgs = append(msgs, &storagetypes.MsgSubmitExecutionResult{
+			Operator:      account.Address,
+			TaskId:        math.NewUint(uint64(tasks[idx].TaskId)),
+			Status:        uint32(result.Status),
+			ResultDataUri: result.EncodeUri(),
+		})
+	}
+
+	sequence, endpoint, err := account.NextSequence(context.Background())
+	if err != nil {
+		return err
+	}
+	cli := account.TxClient(endpoint)
+
+	txOption, count, err := s.txOption(context.Background(), cli, msgs, sequence)
+	var simErr *simulateError
+	if errors.As(err, &simErr) && len(tasks) > 1 {
+		return s.failSimulation(account, sequence, tasks, simErr, func(idx int) error {
+			_, _, err := s.txOption(context.Background(), cli, msgs[idx:idx+1], sequence)
+			return err
+		})
+	}
+	if err != nil {
+		account.Release(sequence)
+		return s.failSubmission(account, tasks, fmt.Errorf("decide the fee of execution results error: %s", err.Error()))
+	}
+	tasks, msgs = tasks[:count], msgs[:count]
+
+	broadcastRes, err := cli.BroadcastTx(context.Background(), msgs, txOption)
+	if err != nil {
+		account.Resync()
+		return s.failSubmission(account, tasks, err)
+	}
+	res := broadcastRes.TxResponse
 
-		err = s.DB.Model(&model.ExecutionTask{}).Where("task_id = ?", task.TaskId).Updates(map[string]interface{}{
-			"status":         model.ExecutionTaskStatusStatusReceiptSubmitted,
-			"submit_tx_hash": res.TxHash,
-		}).Error
+	now := time.Now().Unix()
+	for idx := range tasks {
+		task := &tasks[idx]
+		util.Logger.Infof("submit execution result, task_id=%d, status=%d, gas_used=%d, txHash=%s, code=%d",
+			task.TaskId, task.ResultStatus, task.GasUsed, res.TxHash, res.Code)
+		task.SubmitAccount = account.Address
+		task.SubmitTxHash = res.TxHash
+		task.SubmitAttempts++
+		task.SubmitTime = now
+	}
+	util.Logger.Infof("submit execution results, count=%d, account=%s, sequence=%d, endpoint=%s, txHash=%s, code=%d, gas_limit=%d, fee=%s",
+		len(tasks), account.Address, sequence, endpoint, res.TxHash, res.Code, txOption.GasLimit, txOption.FeeAmount.String())
+
+	if res.Code != 0 {
+		// rejected before inclusion by check tx, e.g. account sequence mismatch, the sequence is not taken
+		account.Resync()
+		return s.handleBatchResult(tasks, res)
+	}
+	submissionCounter.WithLabelValues(account.Address).Inc()
+	submittedResultCounter.WithLabelValues(account.Address).Add(float64(len(tasks)))
+	for _, fee := range txOption.FeeAmount {
+		amount, _ := new(big.Float).SetInt(fee.Amount.BigInt()).Float64()
+		feeCounter.WithLabelValues(fee.Denom).Add(amount)
+	}
+	for idx := range tasks {
+		if err := s.updateTask(&tasks[idx], model.ExecutionTaskStatusStatusReceiptPending); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confirm waits for the inclusion of the receipt txs broadcast
+func (s *Sender) confirm() {
+	for {
+		time.Sleep(common.SenderSendInterval)
+		s.confirmHeartbeat.Beat()
+
+		tasks, err := s.Store.PendingResults()
 		if err != nil {
-			util.Logger.Errorf("update execution task status error: %s", err.Error())
+			util.Logger.Errorf("get pending execution results error: %s", err.Error())
 			continue
 		}
+
+		// the tasks submitted in one tx are confirmed together
+		var txHashes []string
+		batches := make(map[string][]model.ExecutionTask)
+		for _, task := range tasks {
+			if _, ok := batches[task.SubmitTxHash]; !ok {
+				txHashes = append(txHashes, task.SubmitTxHash)
+			}
+			batches[task.SubmitTxHash] = append(batches[task.SubmitTxHash], task)
+		}
+
+		// each batch may wait up to the tx query timeout, so the heartbeat is beaten per batch
+		for _, txHash := range txHashes {
+			if err := s.confirmBatch(batches[txHash]); err != nil {
+				util.Logger.Errorf("confirm execution results error, txHash=%s, err=%s", txHash, err.Error())
+			}
+			s.confirmHeartbeat.Beat()
+		}
+	}
+}
+
+func (s *Sender) confirmBatch(tasks []model.ExecutionTask) error {
+	ctx, cancel := context.WithTimeout(context.Background(), common.SenderTxQueryTimeout)
+	defer cancel()
+
+	txHash, submitTime := tasks[0].SubmitTxHash, tasks[0].SubmitTime
+	account := s.account(tasks[0].SubmitAccount)
+	res, err := account.Client().WaitForTx(ctx, txHash)
+	if err != nil {
+		if time.Now().Unix()-submitTime < common.SenderInclusionTimeout {
+			return nil
+		}
+		// the tx may be dropped from the mempool, leaving a gap in the sequences
+		account.Resync()
+		for idx := range tasks {
+			err := s.retryOrFail(&tasks[idx], 0, 0, fmt.Sprintf("tx %s not included in %d seconds", txHash, common.SenderInclusionTimeout), true)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return s.handleBatchResult(tasks, res)
+}
+
+// account returns the account of the address, the first account if it is not found
+func (s *Sender) account(address string) *Account {
+	for _, account := range s.accounts {
+		if account.Address == address {
+			return account
+		}
+	}
+	return s.accounts[0]
+}
+
+// handleBatchResult records the result of the receipt tx of tasks. a failed msg fails the whole tx, so only the
+// task of the failed msg takes the failure if the msg is known, the others are resubmitted without using an attempt
+func (s *Sender) handleBatchResult(tasks []model.ExecutionTask, res *sdk.TxResponse) error {
+	failedIdx, ok := failedMsgIndex(res.RawLog)
+	for idx := range tasks {
+		var err error
+		if res.Code == 0 || len(tasks) == 1 || !ok || idx == failedIdx || failedIdx >= len(tasks) {
+			err = s.handleTxResult(&tasks[idx], res)
+		} else {
+			tasks[idx].SubmitAttempts--
+			util.Logger.Infof("batch of receipt tx failed, resubmit it, task_id=%d, txHash=%s", tasks[idx].TaskId, res.TxHash)
+			err = s.updateTask(&tasks[idx], model.ExecutionTaskStatusStatusExecuted)
+		}
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (s *Sender) getResultToSubmit() (*model.ExecutionTask, error) {
-	task := model.ExecutionTask{}
-	err := s.DB.Where("status = ?", model.ExecutionTaskStatusStatusExecuted).Order("task_id asc").Take(&task).Error
+// failedMsgIndex returns the index of the msg failing the tx from its log,
+// e.g. "failed to execute message; message index: 1: execution result submitted"
+func failedMsgIndex(rawLog string) (int, bool) {
+	matches := failedMsgIndexRegexp.FindStringSubmatch(rawLog)
+	if len(matches) != 2 {
+		return 0, false
+	}
+	idx, err := strconv.Atoi(matches[1])
 	if err != nil {
-		return nil, err
+		return 0, false
+	}
+	return idx, true
+}
+
+// handleTxResult records the result of the receipt tx, the failed tx is resubmitted
+func (s *Sender) handleTxResult(task *model.ExecutionTask, res *sdk.TxResponse) error {
+	resultSubmitted := storagetypes.ErrExecutionResultSubmitted
+	if res.Code != 0 && !(res.Codespace == resultSubmitted.Codespace() && res.Code == resultSubmitted.ABCICode()) {
+		return s.retryOrFail(task, res.Height, res.Code, res.RawLog, isRetryable(res.Codespace, res.Code))
+	}
+
+	task.SubmitTxHeight = res.Height
+	task.SubmitTxCode = res.Code
+	if res.Code != 0 {
+		// a former tx of the task was included already, maybe of another account
+		util.Logger.Infof("execution result submitted already, task_id=%d, txHash=%s", task.TaskId, res.TxHash)
+		s.updateSubmitter(task)
+	} else {
+		util.Logger.Infof("execution result included, task_id=%d, txHash=%s, height=%d, gas_wanted=%d, gas_used=%d",
+			task.TaskId, res.TxHash, res.Height, res.GasWanted, res.GasUsed)
+		task.ResultSubmitter = task.SubmitAccount
+		includedResultCounter.Inc()
 	}
-	return &task, nil
+	return s.updateTask(task, model.ExecutionTaskStatusStatusReceiptSubmitted)
+}
+
+// updateSubmitter sets the submitter and the tx of the result on chain to the task, the task is left as it is if
+// the result is not found
+func (s *Sender) updateSubmitter(task *model.ExecutionTask) {
+	result, err := s.account(task.SubmitAccount).GetExecutionResult(context.Background(), task.TaskId)
+	if err != nil {
+		util.Logger.Errorf("query execution result error, task_id=%d, err=%s", task.TaskId, err.Error())
+		return
+	}
+	if result == nil {
+		util.Logger.Errorf("execution result not found on chain, task_id=%d", task.TaskId)
+		return
+	}
+
+	setSubmitter(task, result)
+}
+
+// setSubmitter sets the submitter of the result on chain and the tx including it to the task
+func setSubmitter(task *model.ExecutionTask, result *client.ExecutionResult) {
+	util.Logger.Infof("execution result exists on chain, task_id=%d, status=%d, submitter=%s, txHash=%s",
+		task.TaskId, result.Status, result.Submitter, result.TxHash)
+	task.ResultSubmitter = result.Submitter
+	if result.TxHash != "" {
+		task.SubmitTxHash = result.TxHash
+		task.SubmitTxHeight = result.Height
+		task.SubmitTxCode = 0
+	}
+}
+
+// failSimulation charges the failed simulation of a batch to the tasks failing it, so one bad result does not make
+// the others back off. the msg named by the error fails alone, otherwise each task is simulated alone, and all tasks
+// take the failure only if none fails alone. the tasks not charged are submitted in the next batch
+func (s *Sender) failSimulation(account *Account, sequence uint64, tasks []model.ExecutionTask, simErr *simulateError, simulate func(idx int) error) error {
+	// no tx is broadcast, the next batch takes the sequence
+	defer account.Release(sequence)
+
+	if idx, ok := failedMsgIndex(simErr.Error()); ok && idx < simErr.count && idx < len(tasks) {
+		util.Logger.Errorf("simulate execution results error, task_id=%d, err=%s", tasks[idx].TaskId, simErr.Error())
+		return s.failSubmission(account, tasks[idx:idx+1], simErr)
+	}
+
+	var failed bool
+	for idx := range tasks {
+		err := simulate(idx)
+		if err == nil {
+			continue
+		}
+		failed = true
+		// the error is returned as it is unless the task fails to be saved
+		if failErr := s.failSubmission(account, tasks[idx:idx+1], err); failErr != err {
+			return failErr
+		}
+	}
+	if failed {
+		return simErr
+	}
+	return s.failSubmission(account, tasks, fmt.Errorf("decide the fee of execution results error: %s", simErr.Error()))
+}
+
+// failSubmission records the attempt of tasks failing to be broadcast, they are resubmitted after a backoff so
+// the tasks behind them are not blocked
+func (s *Sender) failSubmission(account *Account, tasks []model.ExecutionTask, err error) error {
+	retryable := isRetryableError(err)
+	for idx := range tasks {
+		tasks[idx].SubmitAccount = account.Address
+		tasks[idx].SubmitAttempts++
+		if err := s.retryOrFail(&tasks[idx], 0, 0, err.Error(), retryable); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// retryOrFail resets the task to be resubmitted after a backoff, or dead-letters it and alerts if the failure is
+// permanent or it runs out of attempts
+func (s *Sender) retryOrFail(task *model.ExecutionTask, height int64, code uint32, reason string, retryable bool) error {
+	task.SubmitTxHeight = height
+	task.SubmitTxCode = code
+	task.SubmitError = reason
+
+	if retryable && task.SubmitAttempts < s.Config.GetMaxSubmitAttempts() {
+		interval := backoff(task.SubmitAttempts)
+		util.Logger.Errorf("submit execution result failed, resubmit it, task_id=%d, txHash=%s, attempts=%d, backoff=%s, reason=%s",
+			task.TaskId, task.SubmitTxHash, task.SubmitAttempts, interval.String(), reason)
+		task.NextSubmitTime = time.Now().Add(interval).Unix()
+		failureCounter.WithLabelValues(failureOutcomeResubmit).Inc()
+		return s.updateTask(task, model.ExecutionTaskStatusStatusExecuted)
+	}
+
+	msg := fmt.Sprintf("[%s] submit execution result failed, dead-lettered, task_id=%d, txHash=%s, attempts=%d, retryable=%t, reason=%s",
+		s.Config.AlertConfig.Moniker, task.TaskId, task.SubmitTxHash, task.SubmitAttempts, retryable, reason)
+	util.Logger.Error(msg)
+	util.SendSlackMessage(msg)
+	failureCounter.WithLabelValues(failureOutcomeDeadLetter).Inc()
+	return s.updateTask(task, model.ExecutionTaskStatusStatusDeadLetter)
+}
+
+// updateTask saves the submission of the task with the new status
+func (s *Sender) updateTask(task *model.ExecutionTask, status model.ExecutionTaskStatus) error {
+	task.Status = status
+	return s.Store.UpdateSubmission(task)
 }
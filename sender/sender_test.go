@@ -0,0 +1,186 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/client"
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield-execution-provider/store"
+	"github.com/bnb-chain/greenfield-execution-provider/util"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+func newTestSender(t *testing.T) *Sender {
+	return NewSender(store.NewMemoryStore(), &util.SenderConfig{AlertConfig: &util.AlertConfig{Moniker: "test"}}, nil)
+}
+
+func pendingTask(t *testing.T, s *Sender, taskId int64, attempts int) *model.ExecutionTask {
+	task := &model.ExecutionTask{
+		TaskId:         taskId,
+		Status:         model.ExecutionTaskStatusStatusReceiptPending,
+		SubmitTxHash:   "hash",
+		SubmitAttempts: attempts,
+	}
+	require.NoError(t, s.Store.CreateTask(task))
+	return task
+}
+
+func getTask(t *testing.T, s *Sender, taskId int64) model.ExecutionTask {
+	task, err := s.Store.GetTask(taskId)
+	require.NoError(t, err)
+	return *task
+}
+
+func TestHandleTxResult(t *testing.T) {
+	s := newTestSender(t)
+
+	// included successfully
+	task := pendingTask(t, s, 1, 1)
+	require.NoError(t, s.handleTxResult(task, &sdk.TxResponse{TxHash: "hash", Height: 100}))
+	saved := getTask(t, s, 1)
+	require.Equal(t, model.ExecutionTaskStatusStatusReceiptSubmitted, saved.Status)
+	require.Equal(t, int64(100), saved.SubmitTxHeight)
+	require.Equal(t, uint32(0), saved.SubmitTxCode)
+
+	// a former tx was included, the submitter is looked up on chain
+	s.accounts = []*Account{{Address: "a", queryResult: func(ctx context.Context, taskId int64) (*client.ExecutionResult, error) {
+		return &client.ExecutionResult{Submitter: "b", TxHash: "hash0", Height: 90}, nil
+	}}}
+	submitted := storagetypes.ErrExecutionResultSubmitted
+	task = pendingTask(t, s, 2, 2)
+	task.SubmitAccount = "a"
+	require.NoError(t, s.handleTxResult(task, &sdk.TxResponse{Height: 101, Codespace: submitted.Codespace(), Code: submitted.ABCICode()}))
+	saved = getTask(t, s, 2)
+	require.Equal(t, model.ExecutionTaskStatusStatusReceiptSubmitted, saved.Status)
+	require.Equal(t, "b", saved.ResultSubmitter)
+	require.Equal(t, "hash0", saved.SubmitTxHash)
+	require.Equal(t, int64(90), saved.SubmitTxHeight)
+	require.Equal(t, uint32(0), saved.SubmitTxCode)
+
+	// failed, resubmitted
+	task = pendingTask(t, s, 3, 1)
+	require.NoError(t, s.handleTxResult(task, &sdk.TxResponse{Height: 102, Codespace: "sdk", Code: 11, RawLog: "out of gas"}))
+	saved = getTask(t, s, 3)
+	require.Equal(t, model.ExecutionTaskStatusStatusExecuted, saved.Status)
+	require.Equal(t, int64(102), saved.SubmitTxHeight)
+	require.Equal(t, uint32(11), saved.SubmitTxCode)
+
+	// failed with all attempts used
+	task = pendingTask(t, s, 4, common.DefaultSenderMaxSubmitAttempts)
+	require.NoError(t, s.handleTxResult(task, &sdk.TxResponse{Height: 103, Codespace: "sdk", Code: 11, RawLog: "out of gas"}))
+	require.Equal(t, model.ExecutionTaskStatusStatusDeadLetter, getTask(t, s, 4).Status)
+	events, err := s.Store.TaskEvents(4)
+	require.NoError(t, err)
+	require.Equal(t, model.TaskEventDeadLettered, events[len(events)-1].Event)
+	require.Equal(t, "out of gas", events[len(events)-1].Error)
+}
+
+func TestHandleBatchResult(t *testing.T) {
+	s := newTestSender(t)
+
+	idx, ok := failedMsgIndex("failed to execute message; message index: 1: invalid task id")
+	require.True(t, ok)
+	require.Equal(t, 1, idx)
+	_, ok = failedMsgIndex("out of gas in location: WriteFlat")
+	require.False(t, ok)
+
+	// only the task of the failed msg uses an attempt
+	tasks := []model.ExecutionTask{*pendingTask(t, s, 1, 1), *pendingTask(t, s, 2, common.DefaultSenderMaxSubmitAttempts), *pendingTask(t, s, 3, 1)}
+	require.NoError(t, s.handleBatchResult(tasks, &sdk.TxResponse{
+		Height: 100, Codespace: "storage", Code: 3301, RawLog: "failed to execute message; message index: 1: invalid task id",
+	}))
+	for _, taskId := range []int64{1, 3} {
+		saved := getTask(t, s, taskId)
+		require.Equal(t, model.ExecutionTaskStatusStatusExecuted, saved.Status)
+		require.Equal(t, 0, saved.SubmitAttempts)
+	}
+	require.Equal(t, model.ExecutionTaskStatusStatusDeadLetter, getTask(t, s, 2).Status)
+
+	// the failed msg is unknown, all tasks use an attempt
+	tasks = []model.ExecutionTask{*pendingTask(t, s, 4, 1), *pendingTask(t, s, 5, common.DefaultSenderMaxSubmitAttempts)}
+	require.NoError(t, s.handleBatchResult(tasks, &sdk.TxResponse{Height: 101, Codespace: "sdk", Code: 11, RawLog: "out of gas"}))
+	saved := getTask(t, s, 4)
+	require.Equal(t, model.ExecutionTaskStatusStatusExecuted, saved.Status)
+	require.Equal(t, 1, saved.SubmitAttempts)
+	require.Equal(t, model.ExecutionTaskStatusStatusDeadLetter, getTask(t, s, 5).Status)
+
+	// included
+	tasks = []model.ExecutionTask{*pendingTask(t, s, 6, 1), *pendingTask(t, s, 7, 1)}
+	require.NoError(t, s.handleBatchResult(tasks, &sdk.TxResponse{Height: 102}))
+	require.Equal(t, model.ExecutionTaskStatusStatusReceiptSubmitted, getTask(t, s, 6).Status)
+	require.Equal(t, model.ExecutionTaskStatusStatusReceiptSubmitted, getTask(t, s, 7).Status)
+}
+
+func TestNextAccount(t *testing.T) {
+	s := newTestSender(t)
+	s.Config.MaxInflightTxs = 2
+	s.accounts = []*Account{{Address: "a"}, {Address: "b"}}
+
+	// a has two inflight txs
+	for taskId, txHash := range map[int64]string{1: "tx1", 2: "tx1", 3: "tx2"} {
+		require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{
+			TaskId:        taskId,
+			Status:        model.ExecutionTaskStatusStatusReceiptPending,
+			SubmitAccount: "a",
+			SubmitTxHash:  txHash,
+		}))
+	}
+	inflight, err := s.Store.InflightTxs("a")
+	require.NoError(t, err)
+	require.Equal(t, 2, inflight)
+
+	for i := 0; i < 2; i++ {
+		account, err := s.nextAccount()
+		require.NoError(t, err)
+		require.Equal(t, "b", account.Address)
+	}
+
+	// b is busy too
+	require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{
+		TaskId: 4, Status: model.ExecutionTaskStatusStatusReceiptPending, SubmitAccount: "b", SubmitTxHash: "tx3",
+	}))
+	require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{
+		TaskId: 5, Status: model.ExecutionTaskStatusStatusReceiptPending, SubmitAccount: "b", SubmitTxHash: "tx4",
+	}))
+	account, err := s.nextAccount()
+	require.NoError(t, err)
+	require.Nil(t, account)
+}
+
+func TestReconcile(t *testing.T) {
+	s := newTestSender(t)
+	for _, taskId := range []int64{1, 2, 3} {
+		require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{TaskId: taskId, Status: model.ExecutionTaskStatusStatusExecuted}))
+	}
+
+	account := &Account{Address: "a", queryResult: func(ctx context.Context, taskId int64) (*client.ExecutionResult, error) {
+		switch taskId {
+		case 1:
+			return &client.ExecutionResult{Status: 1, Submitter: "b", TxHash: "hash", Height: 10}, nil
+		case 2:
+			return nil, errors.New("connection refused")
+		}
+		return nil, nil
+	}}
+
+	tasks, err := s.Store.NextResultsToSubmit(time.Now().Unix(), 10)
+	require.NoError(t, err)
+	tasks, err = s.reconcile(account, tasks)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.Equal(t, int64(2), tasks[0].TaskId)
+	require.Equal(t, int64(3), tasks[1].TaskId)
+
+	saved := getTask(t, s, 1)
+	require.Equal(t, model.ExecutionTaskStatusStatusReceiptSubmitted, saved.Status)
+	require.Equal(t, "b", saved.ResultSubmitter)
+	require.Equal(t, "hash", saved.SubmitTxHash)
+	require.Equal(t, int64(10), saved.SubmitTxHeight)
+}
@@ -0,0 +1,82 @@
+package sender
+
+import (
+	"math"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+)
+
+// PendingQueue is the results of the sender waiting for submission and inclusion
+type PendingQueue struct {
+	ToSubmit []PendingResult `json:"to_submit"` // the executed tasks, including the ones waiting for a resubmission
+	Inflight []InflightTx    `json:"inflight"`  // the receipt txs broadcast, waiting for inclusion
+	Accounts []AccountQueue  `json:"accounts"`
+}
+
+type PendingResult struct {
+	TaskId         int64  `json:"task_id"`
+	Attempts       int    `json:"attempts"`
+	NextSubmitTime int64  `json:"next_submit_time"`
+	Error          string `json:"error,omitempty"` // of the last attempt
+}
+
+type InflightTx struct {
+	TxHash     string  `json:"tx_hash"`
+	Account    string  `json:"account"`
+	SubmitTime int64   `json:"submit_time"`
+	TaskIds    []int64 `json:"task_ids"`
+}
+
+type AccountQueue struct {
+	Address     string `json:"address"`
+	InflightTxs int    `json:"inflight_txs"`
+}
+
+// PendingQueue returns the results waiting for submission and inclusion
+func (s *Sender) PendingQueue() (*PendingQueue, error) {
+	queue := &PendingQueue{ToSubmit: []PendingResult{}, Inflight: []InflightTx{}, Accounts: []AccountQueue{}}
+
+	tasks, err := s.Store.NextResultsToSubmit(math.MaxInt64, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		queue.ToSubmit = append(queue.ToSubmit, PendingResult{
+			TaskId:         task.TaskId,
+			Attempts:       task.SubmitAttempts,
+			NextSubmitTime: task.NextSubmitTime,
+			Error:          task.SubmitError,
+		})
+	}
+
+	tasks, err = s.Store.PendingResults()
+	if err != nil {
+		return nil, err
+	}
+	queue.Inflight = inflightTxs(tasks)
+
+	for _, account := range s.accounts {
+		inflight, err := s.Store.InflightTxs(account.Address)
+		if err != nil {
+			return nil, err
+		}
+		queue.Accounts = append(queue.Accounts, AccountQueue{Address: account.Address, InflightTxs: inflight})
+	}
+	return queue, nil
+}
+
+// inflightTxs groups the tasks by their receipt txs in order of the first task of each tx
+func inflightTxs(tasks []model.ExecutionTask) []InflightTx {
+	txs := []InflightTx{}
+	indexes := make(map[string]int)
+	for _, task := range tasks {
+		idx, ok := indexes[task.SubmitTxHash]
+		if !ok {
+			idx = len(txs)
+			indexes[task.SubmitTxHash] = idx
+			txs = append(txs, InflightTx{TxHash: task.SubmitTxHash, Account: task.SubmitAccount, SubmitTime: task.SubmitTime})
+		}
+		txs[idx].TaskIds = append(txs[idx].TaskIds, task.TaskId)
+	}
+	return txs
+}
@@ -0,0 +1,40 @@
+package sender
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+)
+
+func TestPendingQueue(t *testing.T) {
+	s := newTestSender(t)
+	s.accounts = []*Account{{Address: "a"}, {Address: "b"}}
+
+	nextSubmitTime := time.Now().Add(time.Minute).Unix()
+	require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{TaskId: 1, Status: model.ExecutionTaskStatusStatusExecuted}))
+	require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{
+		TaskId: 2, Status: model.ExecutionTaskStatusStatusExecuted, SubmitAttempts: 1, NextSubmitTime: nextSubmitTime, SubmitError: "out of gas",
+	}))
+	for taskId, txHash := range map[int64]string{3: "tx1", 4: "tx2", 5: "tx1"} {
+		require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{
+			TaskId: taskId, Status: model.ExecutionTaskStatusStatusReceiptPending, SubmitAccount: "a", SubmitTxHash: txHash,
+		}))
+	}
+	require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{TaskId: 6, Status: model.ExecutionTaskStatusStatusReceiptSubmitted}))
+
+	queue, err := s.PendingQueue()
+	require.NoError(t, err)
+	// the results waiting for a resubmission are included
+	require.Equal(t, []PendingResult{
+		{TaskId: 1},
+		{TaskId: 2, Attempts: 1, NextSubmitTime: nextSubmitTime, Error: "out of gas"},
+	}, queue.ToSubmit)
+	require.Equal(t, []InflightTx{
+		{TxHash: "tx1", Account: "a", TaskIds: []int64{3, 5}},
+		{TxHash: "tx2", Account: "a", TaskIds: []int64{4}},
+	}, queue.Inflight)
+	require.Equal(t, []AccountQueue{{Address: "a", InflightTxs: 2}, {Address: "b", InflightTxs: 0}}, queue.Accounts)
+}
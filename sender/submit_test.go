@@ -0,0 +1,75 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/bnb-chain/greenfield-execution-provider/client"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+	"github.com/bnb-chain/greenfield/sdk/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+// fakeTxClient fails the simulation of the batches with the bad task, and records the sequences broadcast
+type fakeTxClient struct {
+	badTaskId int64
+	sequences []uint64
+}
+
+func (c *fakeTxClient) SimulateTx(ctx context.Context, msgs []sdk.Msg, txOpt types.TxOption, opts ...grpc.CallOption) (*tx.SimulateResponse, error) {
+	for idx, msg := range msgs {
+		if msg.(*storagetypes.MsgSubmitExecutionResult).TaskId.Uint64() == uint64(c.badTaskId) {
+			return nil, fmt.Errorf("failed to execute message; message index: %d: invalid task status", idx)
+		}
+	}
+	return &tx.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000, MinGasPrice: "5000000000BNB"}}, nil
+}
+
+func (c *fakeTxClient) BroadcastTx(ctx context.Context, msgs []sdk.Msg, txOpt types.TxOption, opts ...grpc.CallOption) (*tx.BroadcastTxResponse, error) {
+	c.sequences = append(c.sequences, txOpt.Nonce)
+	return &tx.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: fmt.Sprintf("hash%d", txOpt.Nonce)}}, nil
+}
+
+func TestSubmitAfterFailedSimulation(t *testing.T) {
+	s := newTestSender(t)
+	txClient := &fakeTxClient{badTaskId: 2}
+	account := newTestAccount(map[string]uint64{"e1": 5}, map[string]bool{"e1": true}, "e1")
+	account.txClient = func(endpoint string) TxClient { return txClient }
+	account.queryResult = func(ctx context.Context, taskId int64) (*client.ExecutionResult, error) { return nil, nil }
+
+	for _, taskId := range []int64{1, 2, 3} {
+		require.NoError(t, s.Store.CreateTask(&model.ExecutionTask{TaskId: taskId, Status: model.ExecutionTaskStatusStatusExecuted}))
+	}
+	tasks, err := s.Store.NextResultsToSubmit(time.Now().Unix(), 10)
+	require.NoError(t, err)
+
+	// the bad task fails the batch alone, nothing is broadcast
+	require.Error(t, s.submit(account, tasks))
+	require.Empty(t, txClient.sequences)
+	require.Equal(t, 1, getTask(t, s, 2).SubmitAttempts)
+	for _, taskId := range []int64{1, 3} {
+		require.Equal(t, 0, getTask(t, s, taskId).SubmitAttempts)
+		require.Equal(t, model.ExecutionTaskStatusStatusExecuted, getTask(t, s, taskId).Status)
+	}
+
+	// the good batch takes the sequence not used
+	tasks, err = s.Store.NextResultsToSubmit(time.Now().Unix(), 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.NoError(t, s.submit(account, tasks))
+	require.Equal(t, []uint64{5}, txClient.sequences)
+	for _, taskId := range []int64{1, 3} {
+		task := getTask(t, s, taskId)
+		require.Equal(t, model.ExecutionTaskStatusStatusReceiptPending, task.Status)
+		require.Equal(t, "hash5", task.SubmitTxHash)
+		require.Equal(t, 1, task.SubmitAttempts)
+		require.Empty(t, task.SubmitError)
+	}
+}
@@ -0,0 +1,516 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+)
+
+// memoryData is the rows kept by MemoryStore, the ids are assigned in increasing order like auto increment columns
+type memoryData struct {
+	blockLogs  []model.BlockLog // in order of height
+	eventLogs  []model.EventLog // in order of id
+	tasks      []model.ExecutionTask
+	taskEvents []model.TaskEvent // in order of id
+
+	nextBlockLogId  int64
+	nextEventLogId  int64
+	nextTaskId      int64
+	nextTaskEventId int64
+}
+
+func (d *memoryData) clone() *memoryData {
+	cloned := *d
+	cloned.blockLogs = append([]model.BlockLog(nil), d.blockLogs...)
+	cloned.eventLogs = append([]model.EventLog(nil), d.eventLogs...)
+	cloned.tasks = append([]model.ExecutionTask(nil), d.tasks...)
+	cloned.taskEvents = append([]model.TaskEvent(nil), d.taskEvents...)
+	return &cloned
+}
+
+// MemoryStore is the store in memory, it is meant for the tests of the components using the store
+type MemoryStore struct {
+	mtx  sync.Mutex
+	data *memoryData
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: &memoryData{nextBlockLogId: 1, nextEventLogId: 1, nextTaskId: 1, nextTaskEventId: 1}}
+}
+
+// Transaction runs fn on a copy of the data which replaces the data if fn succeeds, the store is locked until then
+func (s *MemoryStore) Transaction(fn func(tx Store) error) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	tx := &MemoryStore{data: s.data.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.data = tx.data
+	return nil
+}
+
+func (s *MemoryStore) Ping() error {
+	return nil
+}
+
+func (s *MemoryStore) SaveBlockAndEvents(blockLog *model.BlockLog, eventLogs []*model.EventLog) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	blockLog.Id = s.data.nextBlockLogId
+	s.data.nextBlockLogId++
+	blockLog.BeforeCreate()
+	s.data.blockLogs = append(s.data.blockLogs, *blockLog)
+	sort.SliceStable(s.data.blockLogs, func(i, j int) bool { return s.data.blockLogs[i].Height < s.data.blockLogs[j].Height })
+
+	for _, eventLog := range eventLogs {
+		eventLog.Id = s.data.nextEventLogId
+		s.data.nextEventLogId++
+		eventLog.BeforeCreate()
+		s.data.eventLogs = append(s.data.eventLogs, *eventLog)
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetCurrentBlockLog() (*model.BlockLog, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(s.data.blockLogs) == 0 {
+		return &model.BlockLog{}, nil
+	}
+	blockLog := s.data.blockLogs[len(s.data.blockLogs)-1]
+	return &blockLog, nil
+}
+
+func (s *MemoryStore) GetBlockLog(height int64) (*model.BlockLog, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, blockLog := range s.data.blockLogs {
+		if blockLog.Height == height {
+			return &blockLog, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) DeleteBlocksAndEventsAbove(height int64) ([]model.ExecutionTask, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	taskIds := make(map[int64]bool)
+	for _, eventLog := range s.data.eventLogs {
+		if eventLog.Height > height && eventLog.EventName == common.ExecutionTaskEvent && eventLog.Status == model.EventStatusProcessed {
+			taskIds[eventLog.TaskId] = true
+		}
+	}
+
+	var executedTasks []model.ExecutionTask
+	s.data.tasks = filter(s.data.tasks, func(task model.ExecutionTask) bool {
+		if !taskIds[task.TaskId] {
+			return true
+		}
+		if task.Status != model.ExecutionTaskStatusStatusInit {
+			executedTasks = append(executedTasks, task)
+			s.addTaskEvent(orphanedEvent(&task, height))
+			return true
+		}
+		s.addTaskEvent(revertedEvent(task.TaskId, height))
+		return false
+	})
+	now := time.Now().Unix()
+	for idx := range s.data.tasks {
+		if taskIds[s.data.tasks[idx].TaskId] {
+			s.data.tasks[idx].Status = model.ExecutionTaskStatusStatusOrphaned
+			s.data.tasks[idx].UpdateTime = now
+		}
+	}
+	s.data.eventLogs = filter(s.data.eventLogs, func(eventLog model.EventLog) bool { return eventLog.Height <= height })
+	s.data.blockLogs = filter(s.data.blockLogs, func(blockLog model.BlockLog) bool { return blockLog.Height <= height })
+	return executedTasks, nil
+}
+
+func (s *MemoryStore) PruneBlockLogs(height int64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.data.blockLogs = filter(s.data.blockLogs, func(blockLog model.BlockLog) bool { return blockLog.Height >= height })
+	return nil
+}
+
+func (s *MemoryStore) ConfirmEvents(height int64, confirmNum int64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now().Unix()
+	for idx := range s.data.eventLogs {
+		eventLog := &s.data.eventLogs[idx]
+		if eventLog.Status == model.EventStatusInit && eventLog.Height <= height+1-confirmNum {
+			eventLog.Status = model.EventStatusConfirmed
+			eventLog.ConfirmedNum = height + 1 - eventLog.Height
+			eventLog.UpdateTime = now
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) NextConfirmedEvent(eventName string) (*model.EventLog, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var next *model.EventLog
+	for idx := range s.data.eventLogs {
+		eventLog := &s.data.eventLogs[idx]
+		if eventLog.Status != model.EventStatusConfirmed || eventLog.EventName != eventName {
+			continue
+		}
+		if next == nil || eventLog.Height < next.Height {
+			next = eventLog
+		}
+	}
+	if next == nil {
+		return nil, ErrNotFound
+	}
+	eventLog := *next
+	return &eventLog, nil
+}
+
+func (s *MemoryStore) MarkEventProcessed(eventLog *model.EventLog) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	eventLog.Status = model.EventStatusProcessed
+	eventLog.UpdateTime = time.Now().Unix()
+	for idx := range s.data.eventLogs {
+		if s.data.eventLogs[idx].Id == eventLog.Id {
+			s.data.eventLogs[idx].Status = eventLog.Status
+			s.data.eventLogs[idx].UpdateTime = eventLog.UpdateTime
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) OutdatedEventLogs(status model.EventStatus, before int64, limit int) ([]model.EventLog, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var eventLogs []model.EventLog
+	for _, eventLog := range s.data.eventLogs {
+		if len(eventLogs) == limit {
+			break
+		}
+		if eventLog.Status == status && eventLog.UpdateTime < before {
+			eventLogs = append(eventLogs, eventLog)
+		}
+	}
+	return eventLogs, nil
+}
+
+func (s *MemoryStore) DeleteEventLogs(ids []int64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	deleted := idSet(ids)
+	s.data.eventLogs = filter(s.data.eventLogs, func(eventLog model.EventLog) bool { return !deleted[eventLog.Id] })
+	return nil
+}
+
+func (s *MemoryStore) CreateTask(task *model.ExecutionTask) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, existing := range s.data.tasks {
+		if existing.TaskId == task.TaskId && existing.Status != model.ExecutionTaskStatusStatusOrphaned {
+			return fmt.Errorf("task %d exists", task.TaskId)
+		}
+	}
+	s.data.tasks = filter(s.data.tasks, func(existing model.ExecutionTask) bool { return existing.TaskId != task.TaskId })
+
+	task.Id = s.data.nextTaskId
+	s.data.nextTaskId++
+	task.BeforeCreate()
+	s.data.tasks = append(s.data.tasks, *task)
+	s.addTaskEvent(createdEvent(task))
+	return nil
+}
+
+func (s *MemoryStore) GetTask(taskId int64) (*model.ExecutionTask, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if task := s.task(taskId); task != nil {
+		cloned := *task
+		return &cloned, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) ListTasks(taskFilter TaskFilter) ([]model.ExecutionTask, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	tasks := filter(s.data.tasks, func(task model.ExecutionTask) bool {
+		return (taskFilter.Status == nil || task.Status == *taskFilter.Status) &&
+			(taskFilter.Operator == "" || task.Operator == taskFilter.Operator) &&
+			(taskFilter.BeforeTaskId == 0 || task.TaskId < taskFilter.BeforeTaskId)
+	})
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].TaskId > tasks[j].TaskId })
+	if taskFilter.Limit != 0 && len(tasks) > taskFilter.Limit {
+		tasks = tasks[:taskFilter.Limit]
+	}
+	return tasks, nil
+}
+
+func (s *MemoryStore) OutdatedTasks(status model.ExecutionTaskStatus, before int64, limit int) ([]model.ExecutionTask, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var tasks []model.ExecutionTask
+	for _, task := range s.data.tasks {
+		if len(tasks) == limit {
+			break
+		}
+		if task.Status == status && task.UpdateTime < before {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+func (s *MemoryStore) DeleteTasks(ids []int64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	deleted := idSet(ids)
+	deletedTaskIds := make(map[int64]bool)
+	for _, task := range s.data.tasks {
+		if deleted[task.Id] {
+			deletedTaskIds[task.TaskId] = true
+		}
+	}
+	s.data.tasks = filter(s.data.tasks, func(task model.ExecutionTask) bool { return !deleted[task.Id] })
+	s.data.taskEvents = filter(s.data.taskEvents, func(event model.TaskEvent) bool { return !deletedTaskIds[event.TaskId] })
+	return nil
+}
+
+func (s *MemoryStore) AddTaskEvent(event *model.TaskEvent) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.addTaskEvent(event)
+	return nil
+}
+
+func (s *MemoryStore) TaskEvents(taskId int64) ([]model.TaskEvent, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	events := filter(s.data.taskEvents, func(event model.TaskEvent) bool { return event.TaskId == taskId })
+	sort.SliceStable(events, func(i, j int) bool { return events[i].EventTime < events[j].EventTime })
+	return events, nil
+}
+
+// addTaskEvent saves the event of a task, the store is locked by the caller
+func (s *MemoryStore) addTaskEvent(event *model.TaskEvent) {
+	event.Id = s.data.nextTaskEventId
+	s.data.nextTaskEventId++
+	event.BeforeCreate()
+	s.data.taskEvents = append(s.data.taskEvents, *event)
+}
+
+func (s *MemoryStore) QueueDepths(excludeIds []int64) ([]OperatorQueue, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var queues []OperatorQueue
+	indexes := make(map[string]int)
+	for _, task := range s.pendingTasks(excludeIds) {
+		idx, ok := indexes[task.Operator]
+		if !ok {
+			idx = len(queues)
+			indexes[task.Operator] = idx
+			queues = append(queues, OperatorQueue{Operator: task.Operator, NextTaskId: task.TaskId})
+		}
+		queues[idx].Depth++
+		if task.TaskId < queues[idx].NextTaskId {
+			queues[idx].NextTaskId = task.TaskId
+		}
+	}
+	sort.Slice(queues, func(i, j int) bool { return queues[i].Operator < queues[j].Operator })
+	return queues, nil
+}
+
+func (s *MemoryStore) PendingTasks(excludeIds []int64) ([]model.ExecutionTask, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	tasks := s.pendingTasks(excludeIds)
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].TaskId < tasks[j].TaskId })
+	return tasks, nil
+}
+
+// pendingTasks returns the tasks not leased waiting for execution, excluding the tasks of the ids
+func (s *MemoryStore) pendingTasks(excludeIds []int64) []model.ExecutionTask {
+	excluded := idSet(excludeIds)
+	now := time.Now().Unix()
+	var tasks []model.ExecutionTask
+	for _, task := range s.data.tasks {
+		if task.Status == model.ExecutionTaskStatusStatusInit && task.LeaseExpireTime <= now && !excluded[task.TaskId] {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+func (s *MemoryStore) ClaimNextTask(taskIds []int64, worker string, leaseExpireTime int64) (*model.ExecutionTask, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now().Unix()
+	for _, taskId := range taskIds {
+		task := s.task(taskId)
+		if task != nil && task.Status == model.ExecutionTaskStatusStatusInit && task.LeaseExpireTime <= now {
+			task.LeaseOwner = worker
+			task.LeaseExpireTime = leaseExpireTime
+			task.ExecutionAttempts++
+			s.addTaskEvent(&model.TaskEvent{TaskId: taskId, Event: model.TaskEventClaimed, Status: task.Status, Worker: worker})
+			cloned := *task
+			return &cloned, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) MarkExecuted(taskId int64, receipt ExecutionReceipt) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	task := s.task(taskId)
+	if task == nil || task.Status != model.ExecutionTaskStatusStatusInit {
+		return nil
+	}
+	task.Status = model.ExecutionTaskStatusStatusExecuted
+	task.GasUsed = receipt.GasUsed
+	task.ResultStatus = receipt.ResultStatus
+	task.ExecutionStatus = receipt.ExecutionStatus
+	task.ResultDataUri = receipt.ResultDataUri
+	task.LogDataUri = receipt.LogDataUri
+	task.AttestationUri = receipt.AttestationUri
+	task.UpdateTime = time.Now().Unix()
+	s.addTaskEvent(&model.TaskEvent{TaskId: taskId, Event: model.TaskEventExecuted, Status: task.Status, Worker: receipt.Worker})
+	return nil
+}
+
+func (s *MemoryStore) MarkDeadLettered(taskId int64, worker string, reason string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	task := s.task(taskId)
+	if task == nil || task.Status != model.ExecutionTaskStatusStatusInit {
+		return nil
+	}
+	task.Status = model.ExecutionTaskStatusStatusDeadLetter
+	task.ExecutionStatus = reason
+	task.UpdateTime = time.Now().Unix()
+	s.addTaskEvent(&model.TaskEvent{TaskId: taskId, Event: model.TaskEventDeadLettered, Status: task.Status, Worker: worker, Error: reason})
+	return nil
+}
+
+func (s *MemoryStore) NextResultsToSubmit(now int64, limit int) ([]model.ExecutionTask, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	tasks := s.tasksOfStatus(model.ExecutionTaskStatusStatusExecuted)
+	tasks = filter(tasks, func(task model.ExecutionTask) bool { return task.NextSubmitTime <= now })
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	return tasks, nil
+}
+
+func (s *MemoryStore) PendingResults() ([]model.ExecutionTask, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.tasksOfStatus(model.ExecutionTaskStatusStatusReceiptPending), nil
+}
+
+func (s *MemoryStore) InflightTxs(account string) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	txHashes := make(map[string]bool)
+	for _, task := range s.data.tasks {
+		if task.Status == model.ExecutionTaskStatusStatusReceiptPending && task.SubmitAccount == account {
+			txHashes[task.SubmitTxHash] = true
+		}
+	}
+	return len(txHashes), nil
+}
+
+func (s *MemoryStore) UpdateSubmission(task *model.ExecutionTask) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	task.UpdateTime = time.Now().Unix()
+	saved := s.task(task.TaskId)
+	if saved == nil {
+		return nil
+	}
+	saved.Status = task.Status
+	saved.SubmitAccount = task.SubmitAccount
+	saved.SubmitTxHash = task.SubmitTxHash
+	saved.SubmitTxHeight = task.SubmitTxHeight
+	saved.SubmitTxCode = task.SubmitTxCode
+	saved.SubmitAttempts = task.SubmitAttempts
+	saved.SubmitTime = task.SubmitTime
+	saved.NextSubmitTime = task.NextSubmitTime
+	saved.SubmitError = task.SubmitError
+	saved.ResultSubmitter = task.ResultSubmitter
+	saved.UpdateTime = task.UpdateTime
+	s.addTaskEvent(submissionEvent(task))
+	return nil
+}
+
+// task returns the saved task of the task id, nil if it does not exist
+func (s *MemoryStore) task(taskId int64) *model.ExecutionTask {
+	for idx := range s.data.tasks {
+		if s.data.tasks[idx].TaskId == taskId {
+			return &s.data.tasks[idx]
+		}
+	}
+	return nil
+}
+
+// tasksOfStatus returns the tasks of the status in order of task id
+func (s *MemoryStore) tasksOfStatus(status model.ExecutionTaskStatus) []model.ExecutionTask {
+	tasks := filter(s.data.tasks, func(task model.ExecutionTask) bool { return task.Status == status })
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].TaskId < tasks[j].TaskId })
+	return tasks
+}
+
+// filter returns the rows kept by the function in a new slice
+func filter[T any](rows []T, keep func(row T) bool) []T {
+	var kept []T
+	for _, row := range rows {
+		if keep(row) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+func idSet(ids []int64) map[int64]bool {
+	set := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,430 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+)
+
+// SQLStore is the store on a sql database
+type SQLStore struct {
+	DB *gorm.DB
+
+	inTx bool // whether DB is a transaction
+}
+
+func NewSQLStore(db *gorm.DB) *SQLStore {
+	return &SQLStore{DB: db}
+}
+
+// Transaction runs fn in a db transaction, the nested transactions are part of the outer one
+func (s *SQLStore) Transaction(fn func(tx Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	tx := s.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+	if err := fn(&SQLStore{DB: tx, inTx: true}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (s *SQLStore) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), common.HealthCheckTimeout)
+	defer cancel()
+	return s.DB.DB().PingContext(ctx)
+}
+
+func (s *SQLStore) SaveBlockAndEvents(blockLog *model.BlockLog, eventLogs []*model.EventLog) error {
+	return s.Transaction(func(tx Store) error {
+		db := tx.(*SQLStore).DB
+		if err := db.Create(blockLog).Error; err != nil {
+			return err
+		}
+		for _, eventLog := range eventLogs {
+			if err := db.Create(eventLog).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLStore) GetCurrentBlockLog() (*model.BlockLog, error) {
+	blockLog := model.BlockLog{}
+	err := s.DB.Order("height desc").Take(&blockLog).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return &blockLog, nil
+}
+
+func (s *SQLStore) GetBlockLog(height int64) (*model.BlockLog, error) {
+	blockLog := model.BlockLog{}
+	if err := s.DB.Where("height = ?", height).Take(&blockLog).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &blockLog, nil
+}
+
+func (s *SQLStore) DeleteBlocksAndEventsAbove(height int64) ([]model.ExecutionTask, error) {
+	var executedTasks []model.ExecutionTask
+	err := s.Transaction(func(tx Store) error {
+		db := tx.(*SQLStore).DB
+
+		var taskIds []int64
+		if err := db.Model(model.EventLog{}).Where("height > ? and event_name = ? and status = ?", height,
+			common.ExecutionTaskEvent, model.EventStatusProcessed).Pluck("task_id", &taskIds).Error; err != nil {
+			return err
+		}
+
+		if len(taskIds) > 0 {
+			now := time.Now().Unix()
+			var revertedIds []int64
+			if err := db.Model(model.ExecutionTask{}).Where("task_id in (?) and status = ?", taskIds,
+				model.ExecutionTaskStatusStatusInit).Pluck("task_id", &revertedIds).Error; err != nil {
+				return err
+			}
+			if err := db.Where("task_id in (?) and status = ?", taskIds, model.ExecutionTaskStatusStatusInit).Delete(model.ExecutionTask{}).Error; err != nil {
+				return err
+			}
+			for _, taskId := range revertedIds {
+				if err := db.Create(revertedEvent(taskId, height)).Error; err != nil {
+					return err
+				}
+			}
+			if err := db.Where("task_id in (?)", taskIds).Find(&executedTasks).Error; err != nil {
+				return err
+			}
+			for idx := range executedTasks {
+				if err := db.Create(orphanedEvent(&executedTasks[idx], height)).Error; err != nil {
+					return err
+				}
+			}
+			if err := db.Model(model.ExecutionTask{}).Where("task_id in (?)", taskIds).Updates(
+				map[string]interface{}{
+					"status":      model.ExecutionTaskStatusStatusOrphaned,
+					"update_time": now,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := db.Where("height > ?", height).Delete(model.EventLog{}).Error; err != nil {
+			return err
+		}
+		return db.Where("height > ?", height).Delete(model.BlockLog{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return executedTasks, nil
+}
+
+func (s *SQLStore) PruneBlockLogs(height int64) error {
+	return s.DB.Where("height < ?", height).Delete(model.BlockLog{}).Error
+}
+
+func (s *SQLStore) ConfirmEvents(height int64, confirmNum int64) error {
+	return s.DB.Model(model.EventLog{}).Where("status = ? and height <= ?",
+		model.EventStatusInit, height+1-confirmNum).Updates(
+		map[string]interface{}{
+			"status":        model.EventStatusConfirmed,
+			"confirmed_num": gorm.Expr("? - height", height+1),
+			"update_time":   time.Now().Unix(),
+		}).Error
+}
+
+func (s *SQLStore) NextConfirmedEvent(eventName string) (*model.EventLog, error) {
+	eventLog := model.EventLog{}
+	err := s.DB.Where("status = ? and event_name = ?", model.EventStatusConfirmed, eventName).
+		Order("height asc, id asc").Take(&eventLog).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &eventLog, nil
+}
+
+func (s *SQLStore) MarkEventProcessed(eventLog *model.EventLog) error {
+	return s.DB.Model(eventLog).Updates(
+		map[string]interface{}{
+			"status":      model.EventStatusProcessed,
+			"update_time": time.Now().Unix(),
+		}).Error
+}
+
+func (s *SQLStore) OutdatedEventLogs(status model.EventStatus, before int64, limit int) ([]model.EventLog, error) {
+	var eventLogs []model.EventLog
+	err := s.DB.Where("status = ? and update_time < ?", status, before).Order("id asc").Limit(limit).Find(&eventLogs).Error
+	return eventLogs, err
+}
+
+func (s *SQLStore) DeleteEventLogs(ids []int64) error {
+	return s.DB.Where("id in (?)", ids).Delete(model.EventLog{}).Error
+}
+
+func (s *SQLStore) CreateTask(task *model.ExecutionTask) error {
+	return s.Transaction(func(tx Store) error {
+		db := tx.(*SQLStore).DB
+		if err := db.Where("task_id = ? and status = ?", task.TaskId, model.ExecutionTaskStatusStatusOrphaned).Delete(model.ExecutionTask{}).Error; err != nil {
+			return err
+		}
+		if err := db.Create(task).Error; err != nil {
+			return err
+		}
+		return db.Create(createdEvent(task)).Error
+	})
+}
+
+func (s *SQLStore) GetTask(taskId int64) (*model.ExecutionTask, error) {
+	task := model.ExecutionTask{}
+	if err := s.DB.Where("task_id = ?", taskId).Take(&task).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &task, nil
+}
+
+func (s *SQLStore) ListTasks(filter TaskFilter) ([]model.ExecutionTask, error) {
+	query := s.DB
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.Operator != "" {
+		query = query.Where("operator = ?", filter.Operator)
+	}
+	if filter.BeforeTaskId != 0 {
+		query = query.Where("task_id < ?", filter.BeforeTaskId)
+	}
+	if filter.Limit != 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var tasks []model.ExecutionTask
+	err := query.Order("task_id desc").Find(&tasks).Error
+	return tasks, err
+}
+
+func (s *SQLStore) OutdatedTasks(status model.ExecutionTaskStatus, before int64, limit int) ([]model.ExecutionTask, error) {
+	var tasks []model.ExecutionTask
+	err := s.DB.Where("status = ? and update_time < ?", status, before).Order("id asc").Limit(limit).Find(&tasks).Error
+	return tasks, err
+}
+
+func (s *SQLStore) DeleteTasks(ids []int64) error {
+	return s.Transaction(func(tx Store) error {
+		db := tx.(*SQLStore).DB
+		var taskIds []int64
+		if err := db.Model(model.ExecutionTask{}).Where("id in (?)", ids).Pluck("task_id", &taskIds).Error; err != nil {
+			return err
+		}
+		if len(taskIds) > 0 {
+			if err := db.Where("task_id in (?)", taskIds).Delete(model.TaskEvent{}).Error; err != nil {
+				return err
+			}
+		}
+		return db.Where("id in (?)", ids).Delete(model.ExecutionTask{}).Error
+	})
+}
+
+func (s *SQLStore) AddTaskEvent(event *model.TaskEvent) error {
+	return s.DB.Create(event).Error
+}
+
+func (s *SQLStore) TaskEvents(taskId int64) ([]model.TaskEvent, error) {
+	var events []model.TaskEvent
+	err := s.DB.Where("task_id = ?", taskId).Order("event_time asc, id asc").Find(&events).Error
+	return events, err
+}
+
+// pendingTasks returns the query of the tasks not leased waiting for execution, excluding the tasks of the ids
+func (s *SQLStore) pendingTasks(excludeIds []int64) *gorm.DB {
+	query := s.DB.Model(&model.ExecutionTask{}).Where("status = ? and lease_expire_time <= ?",
+		model.ExecutionTaskStatusStatusInit, time.Now().Unix())
+	if len(excludeIds) > 0 {
+		query = query.Where("task_id not in (?)", excludeIds)
+	}
+	return query
+}
+
+func (s *SQLStore) QueueDepths(excludeIds []int64) ([]OperatorQueue, error) {
+	var queues []OperatorQueue
+	err := s.pendingTasks(excludeIds).Select("operator, count(*) as depth, min(task_id) as next_task_id").
+		Group("operator").Order("operator asc").Scan(&queues).Error
+	if err != nil {
+		return nil, err
+	}
+	return queues, nil
+}
+
+func (s *SQLStore) PendingTasks(excludeIds []int64) ([]model.ExecutionTask, error) {
+	var tasks []model.ExecutionTask
+	err := s.pendingTasks(excludeIds).Select("task_id, operator, max_gas, create_time").Order("task_id asc").Find(&tasks).Error
+	return tasks, err
+}
+
+func (s *SQLStore) ClaimNextTask(taskIds []int64, worker string, leaseExpireTime int64) (*model.ExecutionTask, error) {
+	if len(taskIds) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var claimed *model.ExecutionTask
+	err := s.Transaction(func(tx Store) error {
+		db := tx.(*SQLStore).DB
+		now := time.Now().Unix()
+
+		// the rows being claimed by other workers are skipped instead of waited for where supported
+		query := db.Where("task_id in (?) and status = ? and lease_expire_time <= ?", taskIds, model.ExecutionTaskStatusStatusInit, now)
+		if option := lockOption(db.Dialect().GetName()); option != "" {
+			query = query.Set("gorm:query_option", option)
+		}
+		var tasks []model.ExecutionTask
+		if err := query.Find(&tasks).Error; err != nil {
+			return err
+		}
+
+		for _, taskId := range taskIds {
+			for idx := range tasks {
+				if tasks[idx].TaskId != taskId {
+					continue
+				}
+				// the update is conditional for the dialects without row locks, e.g. sqlite
+				res := db.Model(&model.ExecutionTask{}).Where("task_id = ? and status = ? and lease_expire_time <= ?",
+					taskId, model.ExecutionTaskStatusStatusInit, now).Updates(
+					map[string]interface{}{
+						"lease_owner":        worker,
+						"lease_expire_time":  leaseExpireTime,
+						"execution_attempts": gorm.Expr("execution_attempts + 1"),
+					})
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected == 1 {
+					claimed = &tasks[idx]
+					claimed.LeaseOwner = worker
+					claimed.LeaseExpireTime = leaseExpireTime
+					claimed.ExecutionAttempts++
+					return db.Create(&model.TaskEvent{TaskId: taskId, Event: model.TaskEventClaimed, Status: claimed.Status, Worker: worker}).Error
+				}
+			}
+		}
+		return ErrNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// lockOption returns the option locking the rows selected for update in the dialect
+func lockOption(dialect string) string {
+	switch dialect {
+	case common.DBDialectPostgres:
+		return "FOR UPDATE SKIP LOCKED"
+	case common.DBDialectMysql:
+		return "FOR UPDATE"
+	}
+	return ""
+}
+
+func (s *SQLStore) MarkExecuted(taskId int64, receipt ExecutionReceipt) error {
+	return s.Transaction(func(tx Store) error {
+		db := tx.(*SQLStore).DB
+		res := db.Model(&model.ExecutionTask{}).Where("status = ? and task_id = ?", model.ExecutionTaskStatusStatusInit,
+			taskId).Updates(
+			map[string]interface{}{
+				"status":           model.ExecutionTaskStatusStatusExecuted,
+				"gas_used":         receipt.GasUsed,
+				"result_status":    receipt.ResultStatus,
+				"execution_status": receipt.ExecutionStatus,
+				"result_data_uri":  receipt.ResultDataUri,
+				"log_data_uri":     receipt.LogDataUri,
+				"attestation_uri":  receipt.AttestationUri,
+				"update_time":      time.Now().Unix(),
+			})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return db.Create(&model.TaskEvent{TaskId: taskId, Event: model.TaskEventExecuted,
+			Status: model.ExecutionTaskStatusStatusExecuted, Worker: receipt.Worker}).Error
+	})
+}
+
+func (s *SQLStore) MarkDeadLettered(taskId int64, worker string, reason string) error {
+	return s.Transaction(func(tx Store) error {
+		db := tx.(*SQLStore).DB
+		res := db.Model(&model.ExecutionTask{}).Where("status = ? and task_id = ?", model.ExecutionTaskStatusStatusInit,
+			taskId).Updates(
+			map[string]interface{}{
+				"status":           model.ExecutionTaskStatusStatusDeadLetter,
+				"execution_status": reason,
+				"update_time":      time.Now().Unix(),
+			})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return db.Create(&model.TaskEvent{TaskId: taskId, Event: model.TaskEventDeadLettered,
+			Status: model.ExecutionTaskStatusStatusDeadLetter, Worker: worker, Error: reason}).Error
+	})
+}
+
+func (s *SQLStore) NextResultsToSubmit(now int64, limit int) ([]model.ExecutionTask, error) {
+	var tasks []model.ExecutionTask
+	err := s.DB.Where("status = ? and next_submit_time <= ?", model.ExecutionTaskStatusStatusExecuted, now).
+		Order("task_id asc").Limit(limit).Find(&tasks).Error
+	return tasks, err
+}
+
+func (s *SQLStore) PendingResults() ([]model.ExecutionTask, error) {
+	var tasks []model.ExecutionTask
+	err := s.DB.Where("status = ?", model.ExecutionTaskStatusStatusReceiptPending).Order("task_id asc").Find(&tasks).Error
+	return tasks, err
+}
+
+func (s *SQLStore) InflightTxs(account string) (int, error) {
+	var count int
+	err := s.DB.Model(&model.ExecutionTask{}).Where("status = ? and submit_account = ?", model.ExecutionTaskStatusStatusReceiptPending, account).
+		Select("count(distinct submit_tx_hash)").Row().Scan(&count)
+	return count, err
+}
+
+func (s *SQLStore) UpdateSubmission(task *model.ExecutionTask) error {
+	task.UpdateTime = time.Now().Unix()
+	return s.Transaction(func(tx Store) error {
+		db := tx.(*SQLStore).DB
+		err := db.Model(&model.ExecutionTask{}).Where("task_id = ?", task.TaskId).Updates(
+			map[string]interface{}{
+				"status":           task.Status,
+				"submit_account":   task.SubmitAccount,
+				"submit_tx_hash":   task.SubmitTxHash,
+				"submit_tx_height": task.SubmitTxHeight,
+				"submit_tx_code":   task.SubmitTxCode,
+				"submit_attempts":  task.SubmitAttempts,
+				"submit_time":      task.SubmitTime,
+				"next_submit_time": task.NextSubmitTime,
+				"submit_error":     task.SubmitError,
+				"result_submitter": task.ResultSubmitter,
+				"update_time":      task.UpdateTime,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return db.Create(submissionEvent(task)).Error
+	})
+}
+
+// notFound converts the not found error of gorm to ErrNotFound
+func notFound(err error) error {
+	if err == gorm.ErrRecordNotFound {
+		return ErrNotFound
+	}
+	return err
+}
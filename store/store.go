@@ -0,0 +1,144 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+)
+
+// ErrNotFound is returned when the record queried does not exist
+var ErrNotFound = errors.New("record not found")
+
+// OperatorQueue is the pending tasks of an operator
+type OperatorQueue struct {
+	Operator   string `json:"operator"`
+	Depth      int    `json:"depth"`
+	NextTaskId int64  `json:"next_task_id"`
+}
+
+// TaskFilter selects the tasks listed, the zero values are not filtered
+type TaskFilter struct {
+	Status       *model.ExecutionTaskStatus
+	Operator     string
+	BeforeTaskId int64 // only the tasks of lower task ids, to page through the tasks
+	Limit        int
+}
+
+// ExecutionReceipt is the result of an executed task
+type ExecutionReceipt struct {
+	Worker          string // the executor executing the task
+	GasUsed         int64
+	ResultStatus    model.ExecutionResultStatus
+	ExecutionStatus string
+	ResultDataUri   string
+	LogDataUri      string
+	AttestationUri  string
+}
+
+// Store is the storage of the blocks, events and execution tasks shared by observer, executor and sender. the
+// transitions of the tasks are recorded as task events along with them
+type Store interface {
+	// Transaction calls fn with a store whose writes are committed if fn returns nil, or discarded otherwise
+	Transaction(fn func(tx Store) error) error
+	// Ping checks the connection to the database
+	Ping() error
+
+	// SaveBlockAndEvents saves the block and its events
+	SaveBlockAndEvents(blockLog *model.BlockLog, eventLogs []*model.EventLog) error
+	// GetCurrentBlockLog returns the highest block, an empty block if there is none
+	GetCurrentBlockLog() (*model.BlockLog, error)
+	// GetBlockLog returns the block at the height, ErrNotFound if it is not saved
+	GetBlockLog(height int64) (*model.BlockLog, error)
+	// DeleteBlocksAndEventsAbove deletes the blocks and events higher than the height, and the tasks created from
+	// the deleted events which are not executed yet. the other tasks of the deleted events are orphaned, so their
+	// results are not submitted, and returned as they were
+	DeleteBlocksAndEventsAbove(height int64) ([]model.ExecutionTask, error)
+	// PruneBlockLogs deletes the blocks lower than the height
+	PruneBlockLogs(height int64) error
+
+	// ConfirmEvents confirms the events reaching the confirmation number at the height
+	ConfirmEvents(height int64, confirmNum int64) error
+	// NextConfirmedEvent returns the earliest confirmed event of the event type, ErrNotFound if there is none
+	NextConfirmedEvent(eventName string) (*model.EventLog, error)
+	// MarkEventProcessed marks the event processed
+	MarkEventProcessed(eventLog *model.EventLog) error
+	// OutdatedEventLogs returns the events of the status not updated since the time, in order of id
+	OutdatedEventLogs(status model.EventStatus, before int64, limit int) ([]model.EventLog, error)
+	// DeleteEventLogs deletes the events of the ids
+	DeleteEventLogs(ids []int64) error
+
+	// CreateTask saves the new execution task. the orphaned task of the same id is replaced, as the task is invoked
+	// again by the chain after the reorg, the other task of the same id fails it
+	CreateTask(task *model.ExecutionTask) error
+	// GetTask returns the task of the task id, ErrNotFound if it does not exist
+	GetTask(taskId int64) (*model.ExecutionTask, error)
+	// ListTasks returns the tasks selected by the filter, in descending order of task id
+	ListTasks(filter TaskFilter) ([]model.ExecutionTask, error)
+	// OutdatedTasks returns the tasks of the status not updated since the time, in order of id
+	OutdatedTasks(status model.ExecutionTaskStatus, before int64, limit int) ([]model.ExecutionTask, error)
+	// DeleteTasks deletes the tasks of the ids and their events
+	DeleteTasks(ids []int64) error
+	// AddTaskEvent saves the event of a task not recorded by the store, e.g. the progress of the execution
+	AddTaskEvent(event *model.TaskEvent) error
+	// TaskEvents returns the events of the task in order of time
+	TaskEvents(taskId int64) ([]model.TaskEvent, error)
+
+	// QueueDepths returns the pending tasks not leased of each operator, excluding the tasks of the ids
+	QueueDepths(excludeIds []int64) ([]OperatorQueue, error)
+	// PendingTasks returns the tasks not leased waiting for execution, excluding the tasks of the ids
+	PendingTasks(excludeIds []int64) ([]model.ExecutionTask, error)
+	// ClaimNextTask leases the first of the tasks of the ids which is still pending and not leased to the worker until
+	// the lease expire time, ErrNotFound if there is none. a task is leased to one worker at a time, and the claims
+	// are counted as its execution attempts
+	ClaimNextTask(taskIds []int64, worker string, leaseExpireTime int64) (*model.ExecutionTask, error)
+	// MarkExecuted saves the receipt of the pending task
+	MarkExecuted(taskId int64, receipt ExecutionReceipt) error
+	// MarkDeadLettered gives up the pending task failing all attempts, the reason is saved as its execution status
+	MarkDeadLettered(taskId int64, worker string, reason string) error
+
+	// NextResultsToSubmit returns the executed tasks due to be submitted at the time, in order of task id
+	NextResultsToSubmit(now int64, limit int) ([]model.ExecutionTask, error)
+	// PendingResults returns the tasks whose receipt txs are waiting for inclusion, in order of task id
+	PendingResults() ([]model.ExecutionTask, error)
+	// InflightTxs returns the number of receipt txs of the account waiting for inclusion
+	InflightTxs(account string) (int, error)
+	// UpdateSubmission saves the status and the submission fields of the task
+	UpdateSubmission(task *model.ExecutionTask) error
+}
+
+// createdEvent returns the event of the task created
+func createdEvent(task *model.ExecutionTask) *model.TaskEvent {
+	if task.RejectReason != "" {
+		return &model.TaskEvent{TaskId: task.TaskId, Event: model.TaskEventRejected, Status: task.Status, Error: task.RejectReason}
+	}
+	return &model.TaskEvent{TaskId: task.TaskId, Event: model.TaskEventCreated, Status: task.Status}
+}
+
+// revertedEvent returns the event of the task deleted by the reorg above the height
+func revertedEvent(taskId int64, height int64) *model.TaskEvent {
+	return &model.TaskEvent{TaskId: taskId, Event: model.TaskEventReverted, Status: model.ExecutionTaskStatusStatusInit,
+		Error: fmt.Sprintf("event reverted by the reorg above height %d", height)}
+}
+
+func orphanedEvent(task *model.ExecutionTask, height int64) *model.TaskEvent {
+	return &model.TaskEvent{TaskId: task.TaskId, Event: model.TaskEventOrphaned, Status: model.ExecutionTaskStatusStatusOrphaned,
+		Error: fmt.Sprintf("event reverted by the reorg above height %d, status=%s", height, task.Status)}
+}
+
+// submissionEvent returns the event of the submission of the task updated, the error is kept only for the failures
+// as the last one stays in the task after a later success
+func submissionEvent(task *model.ExecutionTask) *model.TaskEvent {
+	event := &model.TaskEvent{TaskId: task.TaskId, Status: task.Status, Worker: task.SubmitAccount}
+	switch task.Status {
+	case model.ExecutionTaskStatusStatusReceiptPending:
+		event.Event = model.TaskEventSubmitted
+	case model.ExecutionTaskStatusStatusReceiptSubmitted:
+		event.Event = model.TaskEventIncluded
+	case model.ExecutionTaskStatusStatusDeadLetter:
+		event.Event, event.Error = model.TaskEventDeadLettered, task.SubmitError
+	default:
+		event.Event, event.Error = model.TaskEventResubmit, task.SubmitError
+	}
+	return event
+}
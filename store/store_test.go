@@ -0,0 +1,473 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-execution-provider/common"
+	"github.com/bnb-chain/greenfield-execution-provider/model"
+)
+
+// testStores runs the test against each implementation of the store, the sql store is tested on sqlite always, and
+// on mysql and postgres if TEST_MYSQL_DSN and TEST_POSTGRES_DSN are set
+func testStores(t *testing.T, test func(t *testing.T, st Store)) {
+	t.Run(common.DBDialectSqlite3, func(t *testing.T) {
+		db, err := gorm.Open(common.DBDialectSqlite3, filepath.Join(t.TempDir(), "store.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		require.NoError(t, model.Migrate(db))
+		test(t, NewSQLStore(db))
+	})
+
+	for dialect, env := range map[string]string{common.DBDialectMysql: "TEST_MYSQL_DSN", common.DBDialectPostgres: "TEST_POSTGRES_DSN"} {
+		dialect, env := dialect, env
+		t.Run(dialect, func(t *testing.T) {
+			dsn := os.Getenv(env)
+			if dsn == "" {
+				t.Skipf("%s not set", env)
+			}
+			db, err := gorm.Open(dialect, dsn)
+			require.NoError(t, err)
+			dropTables := func() {
+				require.NoError(t, db.DropTableIfExists(&model.SchemaVersion{}, &model.BlockLog{}, &model.EventLog{}, &model.ExecutionTask{}, &model.TaskEvent{}).Error)
+			}
+			dropTables()
+			t.Cleanup(func() {
+				dropTables()
+				db.Close()
+			})
+			require.NoError(t, model.Migrate(db))
+			test(t, NewSQLStore(db))
+		})
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryStore())
+	})
+}
+
+func saveBlock(t *testing.T, st Store, height int64, taskIds ...int64) {
+	var eventLogs []*model.EventLog
+	for _, taskId := range taskIds {
+		eventLogs = append(eventLogs, &model.EventLog{EventName: common.ExecutionTaskEvent, TaskId: taskId, Height: height})
+	}
+	require.NoError(t, st.SaveBlockAndEvents(&model.BlockLog{Height: height, BlockHash: "hash"}, eventLogs))
+}
+
+func TestBlocksAndEvents(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		blockLog, err := st.GetCurrentBlockLog()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), blockLog.Height)
+
+		saveBlock(t, st, 1, 1)
+		saveBlock(t, st, 2, 2)
+		saveBlock(t, st, 3, 3)
+		blockLog, err = st.GetCurrentBlockLog()
+		require.NoError(t, err)
+		require.Equal(t, int64(3), blockLog.Height)
+		_, err = st.GetBlockLog(4)
+		require.Equal(t, ErrNotFound, err)
+
+		// the events of blocks 1 and 2 are confirmed with 2 confirmations at height 3
+		require.NoError(t, st.ConfirmEvents(3, 2))
+		eventLog, err := st.NextConfirmedEvent(common.ExecutionTaskEvent)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), eventLog.TaskId)
+		require.Equal(t, int64(3), eventLog.ConfirmedNum)
+
+		// the task of an event is created along with marking the event processed
+		for _, taskId := range []int64{1, 2} {
+			eventLog, err := st.NextConfirmedEvent(common.ExecutionTaskEvent)
+			require.NoError(t, err)
+			require.Equal(t, taskId, eventLog.TaskId)
+			require.NoError(t, st.Transaction(func(tx Store) error {
+				if err := tx.MarkEventProcessed(eventLog); err != nil {
+					return err
+				}
+				return tx.CreateTask(&model.ExecutionTask{TaskId: eventLog.TaskId, Status: model.ExecutionTaskStatusStatusInit})
+			}))
+		}
+		_, err = st.NextConfirmedEvent(common.ExecutionTaskEvent)
+		require.Equal(t, ErrNotFound, err)
+		require.NoError(t, st.MarkExecuted(1, ExecutionReceipt{GasUsed: 10}))
+
+		// the pending task of the orphaned block is deleted, the executed one is orphaned and returned
+		executedTasks, err := st.DeleteBlocksAndEventsAbove(0)
+		require.NoError(t, err)
+		require.Len(t, executedTasks, 1)
+		require.Equal(t, int64(1), executedTasks[0].TaskId)
+		require.Equal(t, model.ExecutionTaskStatusStatusExecuted, executedTasks[0].Status)
+		_, err = st.GetTask(2)
+		require.Equal(t, ErrNotFound, err)
+		blockLog, err = st.GetCurrentBlockLog()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), blockLog.Height)
+
+		// the orphaned task is not submitted
+		task, err := st.GetTask(1)
+		require.NoError(t, err)
+		require.Equal(t, model.ExecutionTaskStatusStatusOrphaned, task.Status)
+		tasks, err := st.NextResultsToSubmit(time.Now().Unix(), 10)
+		require.NoError(t, err)
+		require.Len(t, tasks, 0)
+		events, err := st.TaskEvents(1)
+		require.NoError(t, err)
+		require.Equal(t, model.TaskEventOrphaned, events[len(events)-1].Event)
+		require.Equal(t, "event reverted by the reorg above height 0, status=executed", events[len(events)-1].Error)
+
+		// the task invoked again replaces the orphaned one, another task of the same id fails
+		require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: 1, Status: model.ExecutionTaskStatusStatusInit}))
+		task, err = st.GetTask(1)
+		require.NoError(t, err)
+		require.Equal(t, model.ExecutionTaskStatusStatusInit, task.Status)
+		require.Equal(t, int64(0), task.GasUsed)
+		require.Error(t, st.CreateTask(&model.ExecutionTask{TaskId: 1, Status: model.ExecutionTaskStatusStatusInit}))
+	})
+}
+
+func TestTransactionRollback(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		err := st.Transaction(func(tx Store) error {
+			if err := tx.CreateTask(&model.ExecutionTask{TaskId: 1}); err != nil {
+				return err
+			}
+			return errors.New("handle error")
+		})
+		require.Error(t, err)
+		_, err = st.GetTask(1)
+		require.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestPrune(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		saveBlock(t, st, 1, 1, 2)
+		saveBlock(t, st, 2, 3)
+		require.NoError(t, st.PruneBlockLogs(2))
+		_, err := st.GetBlockLog(1)
+		require.Equal(t, ErrNotFound, err)
+
+		before := time.Now().Unix() + 1
+		eventLogs, err := st.OutdatedEventLogs(model.EventStatusInit, before, 2)
+		require.NoError(t, err)
+		require.Len(t, eventLogs, 2)
+		require.NoError(t, st.DeleteEventLogs([]int64{eventLogs[0].Id, eventLogs[1].Id}))
+		eventLogs, err = st.OutdatedEventLogs(model.EventStatusInit, before, 2)
+		require.NoError(t, err)
+		require.Len(t, eventLogs, 1)
+		require.Equal(t, int64(3), eventLogs[0].TaskId)
+
+		require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: 1, Status: model.ExecutionTaskStatusStatusRejected}))
+		tasks, err := st.OutdatedTasks(model.ExecutionTaskStatusStatusRejected, before, 10)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		require.NoError(t, st.DeleteTasks([]int64{tasks[0].Id}))
+		_, err = st.GetTask(1)
+		require.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestExecutionTasks(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		for taskId, operator := range map[int64]string{1: "a", 2: "a", 3: "b"} {
+			require.NoError(t, st.CreateTask(&model.ExecutionTask{
+				TaskId:   taskId,
+				Operator: operator,
+				MaxGas:   "100",
+				Status:   model.ExecutionTaskStatusStatusInit,
+			}))
+		}
+
+		queues, err := st.QueueDepths([]int64{1})
+		require.NoError(t, err)
+		require.Equal(t, []OperatorQueue{
+			{Operator: "a", Depth: 1, NextTaskId: 2},
+			{Operator: "b", Depth: 1, NextTaskId: 3},
+		}, queues)
+		tasks, err := st.PendingTasks(nil)
+		require.NoError(t, err)
+		require.Len(t, tasks, 3)
+		require.Equal(t, "100", tasks[0].MaxGas)
+
+		// the first task still pending is claimed
+		require.NoError(t, st.MarkExecuted(3, ExecutionReceipt{
+			GasUsed:        50,
+			ResultStatus:   model.ExecutionResultStatusSuccess,
+			ResultDataUri:  "10",
+			AttestationUri: "11",
+		}))
+		leaseExpireTime := time.Now().Add(time.Minute).Unix()
+		task, err := st.ClaimNextTask([]int64{3, 2, 1}, "a", leaseExpireTime)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), task.TaskId)
+		_, err = st.ClaimNextTask([]int64{3}, "a", leaseExpireTime)
+		require.Equal(t, ErrNotFound, err)
+
+		task, err = st.GetTask(3)
+		require.NoError(t, err)
+		require.Equal(t, model.ExecutionTaskStatusStatusExecuted, task.Status)
+		require.Equal(t, int64(50), task.GasUsed)
+		require.Equal(t, "11", task.AttestationUri)
+	})
+}
+
+func TestListTasks(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		for taskId := int64(1); taskId <= 5; taskId++ {
+			operator := "a"
+			if taskId%2 == 0 {
+				operator = "b"
+			}
+			require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: taskId, Operator: operator, Status: model.ExecutionTaskStatusStatusInit}))
+		}
+		require.NoError(t, st.MarkExecuted(3, ExecutionReceipt{}))
+
+		taskIds := func(filter TaskFilter) []int64 {
+			tasks, err := st.ListTasks(filter)
+			require.NoError(t, err)
+			var ids []int64
+			for _, task := range tasks {
+				ids = append(ids, task.TaskId)
+			}
+			return ids
+		}
+		status := model.ExecutionTaskStatusStatusInit
+		require.Equal(t, []int64{5, 4, 3, 2, 1}, taskIds(TaskFilter{}))
+		require.Equal(t, []int64{5, 4}, taskIds(TaskFilter{Limit: 2}))
+		require.Equal(t, []int64{3, 2}, taskIds(TaskFilter{BeforeTaskId: 4, Limit: 2}))
+		require.Equal(t, []int64{5, 1}, taskIds(TaskFilter{Status: &status, Operator: "a"}))
+	})
+}
+
+func TestClaimNextTask(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		for _, taskId := range []int64{1, 2} {
+			require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: taskId, Operator: "a", Status: model.ExecutionTaskStatusStatusInit}))
+		}
+
+		// the leased task is claimed by neither the others nor the owner
+		leaseExpireTime := time.Now().Add(time.Minute).Unix()
+		task, err := st.ClaimNextTask([]int64{1, 2}, "a", leaseExpireTime)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), task.TaskId)
+		require.Equal(t, "a", task.LeaseOwner)
+		task, err = st.ClaimNextTask([]int64{1, 2}, "b", leaseExpireTime)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), task.TaskId)
+		_, err = st.ClaimNextTask([]int64{1, 2}, "a", leaseExpireTime)
+		require.Equal(t, ErrNotFound, err)
+
+		tasks, err := st.PendingTasks(nil)
+		require.NoError(t, err)
+		require.Len(t, tasks, 0)
+		queues, err := st.QueueDepths(nil)
+		require.NoError(t, err)
+		require.Len(t, queues, 0)
+
+		// the task of an expired lease is claimed again
+		require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: 3, Operator: "a", Status: model.ExecutionTaskStatusStatusInit}))
+		_, err = st.ClaimNextTask([]int64{3}, "a", time.Now().Unix()-1)
+		require.NoError(t, err)
+		tasks, err = st.PendingTasks(nil)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		task, err = st.ClaimNextTask([]int64{3}, "b", leaseExpireTime)
+		require.NoError(t, err)
+		require.Equal(t, "b", task.LeaseOwner)
+		require.Equal(t, 2, task.ExecutionAttempts)
+		task, err = st.GetTask(3)
+		require.NoError(t, err)
+		require.Equal(t, 2, task.ExecutionAttempts)
+
+		// the task failing all attempts is dead-lettered, it is not claimed again
+		require.NoError(t, st.MarkDeadLettered(3, "b", "download input files error"))
+		task, err = st.GetTask(3)
+		require.NoError(t, err)
+		require.Equal(t, model.ExecutionTaskStatusStatusDeadLetter, task.Status)
+		require.Equal(t, "download input files error", task.ExecutionStatus)
+		_, err = st.ClaimNextTask([]int64{3}, "b", leaseExpireTime)
+		require.Equal(t, ErrNotFound, err)
+		events, err := st.TaskEvents(3)
+		require.NoError(t, err)
+		require.Equal(t, model.TaskEventDeadLettered, events[len(events)-1].Event)
+	})
+}
+
+func TestClaimNextTaskConcurrently(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: 1, Status: model.ExecutionTaskStatusStatusInit}))
+
+		// the workers failing to claim get ErrNotFound, or a busy error on sqlite
+		var wg sync.WaitGroup
+		owners := make(chan string, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(worker string) {
+				defer wg.Done()
+				task, err := st.ClaimNextTask([]int64{1}, worker, time.Now().Add(time.Minute).Unix())
+				if err == nil {
+					owners <- task.LeaseOwner
+				}
+			}(fmt.Sprintf("worker%d", i))
+		}
+		wg.Wait()
+		close(owners)
+
+		var claimed []string
+		for owner := range owners {
+			claimed = append(claimed, owner)
+		}
+		require.Len(t, claimed, 1)
+		task, err := st.GetTask(1)
+		require.NoError(t, err)
+		require.Equal(t, claimed[0], task.LeaseOwner)
+	})
+}
+
+// eventTypes returns the types of the events of the task in order of time
+func eventTypes(t *testing.T, st Store, taskId int64) []model.TaskEventType {
+	events, err := st.TaskEvents(taskId)
+	require.NoError(t, err)
+	var types []model.TaskEventType
+	for _, event := range events {
+		types = append(types, event.Event)
+	}
+	return types
+}
+
+func TestTaskEvents(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: 1, Status: model.ExecutionTaskStatusStatusInit}))
+		_, err := st.ClaimNextTask([]int64{1}, "executor", time.Now().Add(time.Minute).Unix())
+		require.NoError(t, err)
+		require.NoError(t, st.AddTaskEvent(&model.TaskEvent{TaskId: 1, Event: model.TaskEventDownloaded, Worker: "executor"}))
+		require.NoError(t, st.MarkExecuted(1, ExecutionReceipt{Worker: "executor", ResultStatus: model.ExecutionResultStatusSuccess}))
+		// executed once only
+		require.NoError(t, st.MarkExecuted(1, ExecutionReceipt{Worker: "executor"}))
+
+		task, err := st.GetTask(1)
+		require.NoError(t, err)
+		task.SubmitAccount = "sender"
+		task.SubmitError = "out of gas"
+		task.Status = model.ExecutionTaskStatusStatusExecuted
+		require.NoError(t, st.UpdateSubmission(task))
+		task.Status = model.ExecutionTaskStatusStatusReceiptPending
+		require.NoError(t, st.UpdateSubmission(task))
+		task.Status = model.ExecutionTaskStatusStatusReceiptSubmitted
+		require.NoError(t, st.UpdateSubmission(task))
+
+		events, err := st.TaskEvents(1)
+		require.NoError(t, err)
+		require.Equal(t, []model.TaskEventType{model.TaskEventCreated, model.TaskEventClaimed, model.TaskEventDownloaded,
+			model.TaskEventExecuted, model.TaskEventResubmit, model.TaskEventSubmitted, model.TaskEventIncluded}, eventTypes(t, st, 1))
+		require.Equal(t, "executor", events[1].Worker)
+		require.Equal(t, "executor", events[3].Worker)
+		require.Equal(t, model.ExecutionTaskStatusStatusExecuted, events[3].Status)
+		require.Equal(t, "sender", events[4].Worker)
+		require.Equal(t, "out of gas", events[4].Error)
+		// the error of the former failure is not taken by the success
+		require.Equal(t, "", events[6].Error)
+		for idx := 1; idx < len(events); idx++ {
+			require.LessOrEqual(t, events[idx-1].EventTime, events[idx].EventTime)
+		}
+
+		// the rejection is recorded with the reason
+		require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: 2, Status: model.ExecutionTaskStatusStatusRejected, RejectReason: "operator not allowed"}))
+		events, err = st.TaskEvents(2)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, model.TaskEventRejected, events[0].Event)
+		require.Equal(t, "operator not allowed", events[0].Error)
+
+		// the events are deleted with the task
+		tasks, err := st.OutdatedTasks(model.ExecutionTaskStatusStatusReceiptSubmitted, time.Now().Unix()+1, 10)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		require.NoError(t, st.DeleteTasks([]int64{tasks[0].Id}))
+		require.Len(t, eventTypes(t, st, 1), 0)
+		require.Len(t, eventTypes(t, st, 2), 1)
+	})
+}
+
+func TestTaskEventsOfReorg(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		saveBlock(t, st, 1, 1)
+		require.NoError(t, st.ConfirmEvents(1, 1))
+		eventLog, err := st.NextConfirmedEvent(common.ExecutionTaskEvent)
+		require.NoError(t, err)
+		require.NoError(t, st.MarkEventProcessed(eventLog))
+		require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: 1, Status: model.ExecutionTaskStatusStatusInit}))
+
+		// the task deleted by the reorg keeps its history
+		_, err = st.DeleteBlocksAndEventsAbove(0)
+		require.NoError(t, err)
+		events, err := st.TaskEvents(1)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, model.TaskEventReverted, events[1].Event)
+		require.Equal(t, "event reverted by the reorg above height 0", events[1].Error)
+	})
+}
+
+func TestSubmissions(t *testing.T) {
+	testStores(t, func(t *testing.T, st Store) {
+		now := time.Now().Unix()
+		for _, taskId := range []int64{3, 1, 2} {
+			require.NoError(t, st.CreateTask(&model.ExecutionTask{TaskId: taskId, Status: model.ExecutionTaskStatusStatusExecuted}))
+		}
+
+		tasks, err := st.NextResultsToSubmit(now, 2)
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+		require.Equal(t, int64(1), tasks[0].TaskId)
+		require.Equal(t, int64(2), tasks[1].TaskId)
+
+		for idx := range tasks {
+			tasks[idx].Status = model.ExecutionTaskStatusStatusReceiptPending
+			tasks[idx].SubmitAccount = "a"
+			tasks[idx].SubmitTxHash = "tx1"
+			tasks[idx].SubmitAttempts = 1
+			require.NoError(t, st.UpdateSubmission(&tasks[idx]))
+		}
+		inflight, err := st.InflightTxs("a")
+		require.NoError(t, err)
+		require.Equal(t, 1, inflight)
+		pending, err := st.PendingResults()
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		require.Equal(t, "tx1", pending[0].SubmitTxHash)
+
+		// the task backing off is not submitted until its next submit time
+		task, err := st.GetTask(3)
+		require.NoError(t, err)
+		task.NextSubmitTime = now + 60
+		task.SubmitError = "connection refused"
+		require.NoError(t, st.UpdateSubmission(task))
+		tasks, err = st.NextResultsToSubmit(now, 10)
+		require.NoError(t, err)
+		require.Len(t, tasks, 0)
+		task, err = st.GetTask(3)
+		require.NoError(t, err)
+		require.Equal(t, "connection refused", task.SubmitError)
+
+		// the long errors are saved as they are
+		task.SubmitError = strings.Repeat("e", 1000)
+		require.NoError(t, st.UpdateSubmission(task))
+		task, err = st.GetTask(3)
+		require.NoError(t, err)
+		require.Len(t, task.SubmitError, 1000)
+	})
+}
@@ -13,12 +13,61 @@ type ObserverConfig struct {
 	GreenfieldConfig GreenfieldConfig `json:"greenfield_config"`
 	LogConfig        *LogConfig       `json:"log_config"`
 	AlertConfig      *AlertConfig     `json:"alert_config"`
+	HTTPConfig       *HTTPConfig      `json:"http_config"`
+
+	// ConfirmNum is the number of blocks(including the block of the event) needed to confirm an event,
+	// 0 confirms events in the block they are included for greenfield has instant finality.
+	// common.DefaultConfirmNum is used if it is not set.
+	ConfirmNum *int64 `json:"confirm_num"`
+	// CatchUpWindow is the number of blocks fetched concurrently when the observer falls behind the chain,
+	// 1 disables the catch-up mode. common.DefaultCatchUpWindow is used if it is not set.
+	CatchUpWindow int64 `json:"catch_up_window"`
+	// UseWebsocket subscribes to new blocks over the websocket of the rpc node, polling is kept as fallback
+	UseWebsocket bool `json:"use_websocket"`
+
+	PruneConfig     *PruneConfig     `json:"prune_config"`
+	AdmissionConfig *AdmissionConfig `json:"admission_config"`
 }
 
 func (cfg *ObserverConfig) Validate() {
 	cfg.DBConfig.Validate()
+	cfg.GreenfieldConfig.Validate()
 	cfg.LogConfig.Validate()
 	cfg.AlertConfig.Validate()
+	if cfg.HTTPConfig != nil {
+		cfg.HTTPConfig.Validate()
+	}
+	if cfg.ConfirmNum != nil && *cfg.ConfirmNum < 0 {
+		panic("confirm_num should not be negative")
+	}
+	if cfg.CatchUpWindow < 0 {
+		panic("catch_up_window should not be negative")
+	}
+	if cfg.AdmissionConfig != nil {
+		cfg.AdmissionConfig.Validate()
+	}
+	if cfg.PruneConfig != nil {
+		cfg.PruneConfig.Validate()
+		if cfg.PruneConfig.ArchiveBucket != "" && cfg.GreenfieldConfig.PrivateKey == "" {
+			panic("private_key should not be empty if archive to bucket")
+		}
+	}
+}
+
+// GetConfirmNum returns the confirmation number of events
+func (cfg *ObserverConfig) GetConfirmNum() int64 {
+	if cfg.ConfirmNum == nil {
+		return common.DefaultConfirmNum
+	}
+	return *cfg.ConfirmNum
+}
+
+// GetCatchUpWindow returns the number of blocks fetched concurrently in catch-up mode
+func (cfg *ObserverConfig) GetCatchUpWindow() int64 {
+	if cfg.CatchUpWindow == 0 {
+		return common.DefaultCatchUpWindow
+	}
+	return cfg.CatchUpWindow
 }
 
 type ExecutorConfig struct {
@@ -26,12 +75,92 @@ type ExecutorConfig struct {
 	GreenfieldConfig GreenfieldConfig `json:"greenfield_config"`
 	LogConfig        *LogConfig       `json:"log_config"`
 	AlertConfig      *AlertConfig     `json:"alert_config"`
+	SchedulerConfig  *SchedulerConfig `json:"scheduler_config"`
+	HTTPConfig       *HTTPConfig      `json:"http_config"`
 }
 
 func (cfg *ExecutorConfig) Validate() {
 	cfg.DBConfig.Validate()
+	cfg.GreenfieldConfig.Validate()
 	cfg.LogConfig.Validate()
 	cfg.AlertConfig.Validate()
+	if cfg.HTTPConfig != nil {
+		cfg.HTTPConfig.Validate()
+	}
+	if cfg.SchedulerConfig != nil {
+		cfg.SchedulerConfig.Validate()
+	}
+}
+
+// SchedulerConfig is the per-operator quotas and the priority policy of the executor, zero quotas are unlimited
+type SchedulerConfig struct {
+	Window             int64  `json:"window"` // in seconds, common.DefaultSchedulerWindow is used if it is not set
+	MaxTasksPerWindow  int    `json:"max_tasks_per_window"`
+	MaxConcurrentTasks int    `json:"max_concurrent_tasks"`
+	MaxGasPerWindow    uint64 `json:"max_gas_per_window"` // cumulative gas used by the tasks finished in the window
+	// MaxRunningTasks limits the containers running at the same time across operators,
+	// common.DefaultMaxRunningTasks is used if it is not set
+	MaxRunningTasks int `json:"max_running_tasks"`
+	// MaxExecutionAttempts is the number of attempts before a task is dead-lettered,
+	// common.DefaultMaxExecutionAttempts is used if it is not set
+	MaxExecutionAttempts int `json:"max_execution_attempts"`
+
+	// PriorityPolicy is one of common.PriorityPolicyFifo and common.PriorityPolicyMaxGas, fifo is used if it is not set
+	PriorityPolicy string `json:"priority_policy"`
+	// the priority of a waiting task is raised by AgingBoost every AgingInterval seconds, so tasks of low
+	// priority are not starved. aging is disabled if either is zero
+	AgingInterval int64  `json:"aging_interval"`
+	AgingBoost    uint64 `json:"aging_boost"`
+}
+
+func (cfg *SchedulerConfig) Validate() {
+	if cfg.Window < 0 {
+		panic("window should not be negative")
+	}
+	if cfg.MaxTasksPerWindow < 0 || cfg.MaxConcurrentTasks < 0 || cfg.MaxRunningTasks < 0 {
+		panic("quotas should not be negative")
+	}
+	if cfg.MaxExecutionAttempts < 0 {
+		panic("max execution attempts should not be negative")
+	}
+	if cfg.PriorityPolicy != "" && cfg.PriorityPolicy != common.PriorityPolicyFifo && cfg.PriorityPolicy != common.PriorityPolicyMaxGas {
+		panic(fmt.Sprintf("priority policy %s is not supported", cfg.PriorityPolicy))
+	}
+	if cfg.AgingInterval < 0 {
+		panic("aging interval should not be negative")
+	}
+}
+
+// GetPriorityPolicy returns the priority policy of tasks
+func (cfg *SchedulerConfig) GetPriorityPolicy() string {
+	if cfg.PriorityPolicy == "" {
+		return common.PriorityPolicyFifo
+	}
+	return cfg.PriorityPolicy
+}
+
+// GetMaxRunningTasks returns the max number of tasks running at the same time
+func (cfg *SchedulerConfig) GetMaxRunningTasks() int {
+	if cfg.MaxRunningTasks == 0 {
+		return common.DefaultMaxRunningTasks
+	}
+	return cfg.MaxRunningTasks
+}
+
+// GetMaxExecutionAttempts returns the number of attempts before a task is dead-lettered
+func (cfg *SchedulerConfig) GetMaxExecutionAttempts() int {
+	if cfg.MaxExecutionAttempts == 0 {
+		return common.DefaultMaxExecutionAttempts
+	}
+	return cfg.MaxExecutionAttempts
+}
+
+// GetWindow returns the window of quotas in seconds
+func (cfg *SchedulerConfig) GetWindow() int64 {
+	if cfg.Window == 0 {
+		return common.DefaultSchedulerWindow
+	}
+	return cfg.Window
 }
 
 type SenderConfig struct {
@@ -39,6 +168,168 @@ type SenderConfig struct {
 	GreenfieldConfig GreenfieldConfig `json:"greenfield_config"`
 	LogConfig        *LogConfig       `json:"log_config"`
 	AlertConfig      *AlertConfig     `json:"alert_config"`
+	FeeConfig        *FeeConfig       `json:"fee_config"`
+	HTTPConfig       *HTTPConfig      `json:"http_config"`
+
+	// results are submitted in batches of at most MaxBatchSize msgs and MaxBatchGas gas in one tx
+	MaxBatchSize int    `json:"max_batch_size"` // common.DefaultSenderBatchSize is used if it is not set
+	MaxBatchGas  uint64 `json:"max_batch_gas"`  // 0 for no bound
+
+	// ExtraPrivateKeys are the mnemonics of the accounts submitting results along with the account of the
+	// greenfield config, the accounts are used round robin
+	ExtraPrivateKeys []string `json:"extra_private_keys"`
+	// MaxInflightTxs is the max number of receipt txs of an account waiting for inclusion,
+	// common.DefaultSenderMaxInflightTxs is used if it is not set
+	MaxInflightTxs int `json:"max_inflight_txs"`
+	// MaxSubmitAttempts is the number of attempts before a result is dead-lettered,
+	// common.DefaultSenderMaxSubmitAttempts is used if it is not set
+	MaxSubmitAttempts int `json:"max_submit_attempts"`
+}
+
+func (cfg *SenderConfig) Validate() {
+	cfg.DBConfig.Validate()
+	cfg.GreenfieldConfig.Validate()
+	cfg.LogConfig.Validate()
+	cfg.AlertConfig.Validate()
+	if cfg.HTTPConfig != nil {
+		cfg.HTTPConfig.Validate()
+	}
+	if cfg.FeeConfig != nil {
+		cfg.FeeConfig.Validate()
+	}
+	if cfg.MaxBatchSize < 0 {
+		panic("max batch size should not be negative")
+	}
+	if cfg.MaxInflightTxs < 0 {
+		panic("max inflight txs should not be negative")
+	}
+	if cfg.MaxSubmitAttempts < 0 {
+		panic("max submit attempts should not be negative")
+	}
+}
+
+// GetPrivateKeys returns the mnemonics of all accounts submitting results
+func (cfg *SenderConfig) GetPrivateKeys() []string {
+	return append([]string{cfg.GreenfieldConfig.PrivateKey}, cfg.ExtraPrivateKeys...)
+}
+
+// GetMaxSubmitAttempts returns the number of attempts before a result is dead-lettered
+func (cfg *SenderConfig) GetMaxSubmitAttempts() int {
+	if cfg.MaxSubmitAttempts == 0 {
+		return common.DefaultSenderMaxSubmitAttempts
+	}
+	return cfg.MaxSubmitAttempts
+}
+
+// GetMaxInflightTxs returns the max number of receipt txs of an account waiting for inclusion
+func (cfg *SenderConfig) GetMaxInflightTxs() int {
+	if cfg.MaxInflightTxs == 0 {
+		return common.DefaultSenderMaxInflightTxs
+	}
+	return cfg.MaxInflightTxs
+}
+
+// GetMaxBatchSize returns the max number of results submitted in one tx
+func (cfg *SenderConfig) GetMaxBatchSize() int {
+	if cfg.MaxBatchSize == 0 {
+		return common.DefaultSenderBatchSize
+	}
+	return cfg.MaxBatchSize
+}
+
+// GetFeeConfig returns the fee config of receipt txs
+func (cfg *SenderConfig) GetFeeConfig() *FeeConfig {
+	if cfg.FeeConfig == nil {
+		return &FeeConfig{}
+	}
+	return cfg.FeeConfig
+}
+
+// FeeConfig decides the gas limit and the fee of receipt txs. gas_limit and fee_amount of the greenfield
+// config are taken per result and used unless Simulate is set or either of them is not set
+type FeeConfig struct {
+	Simulate      bool    `json:"simulate"`       // estimate the gas limit by simulating the tx
+	GasMultiplier float64 `json:"gas_multiplier"` // applied to the simulated gas, 1 is used if it is not set
+	MaxFeeAmount  uint64  `json:"max_fee_amount"` // txs with higher fees per result are not sent, 0 for no cap
+}
+
+func (cfg *FeeConfig) Validate() {
+	if cfg.GasMultiplier < 0 {
+		panic("gas multiplier should not be negative")
+	}
+}
+
+// GetGasMultiplier returns the multiplier applied to the simulated gas
+func (cfg *FeeConfig) GetGasMultiplier() float64 {
+	if cfg.GasMultiplier == 0 {
+		return 1
+	}
+	return cfg.GasMultiplier
+}
+
+// AdmissionConfig is the policy deciding which execution tasks are admitted, empty allow lists and zero
+// limits are not checked
+type AdmissionConfig struct {
+	AllowedOperators   []string `json:"allowed_operators"`
+	DeniedOperators    []string `json:"denied_operators"`
+	AllowedExecutables []string `json:"allowed_executables"` // executable object ids
+	DeniedExecutables  []string `json:"denied_executables"`
+	AllowedBuckets     []string `json:"allowed_buckets"` // buckets of executables
+	DeniedBuckets      []string `json:"denied_buckets"`
+	MinMaxGas          uint64   `json:"min_max_gas"`
+	MaxMaxGas          uint64   `json:"max_max_gas"`
+	MaxInputCount      int      `json:"max_input_count"`
+
+	// SubmitRejection submits a failed result for the rejected tasks, so the invokers do not wait for them
+	SubmitRejection bool `json:"submit_rejection"`
+}
+
+func (cfg *AdmissionConfig) Validate() {
+	if cfg.MaxMaxGas != 0 && cfg.MaxMaxGas < cfg.MinMaxGas {
+		panic("max_max_gas should not be lower than min_max_gas")
+	}
+	if cfg.MaxInputCount < 0 {
+		panic("max_input_count should not be negative")
+	}
+}
+
+// PruneConfig is the retention policies of event logs and execution tasks, the pruned rows can be
+// archived before deletion
+type PruneConfig struct {
+	EventLogPolicies      []RetentionPolicy `json:"event_log_policies"`
+	ExecutionTaskPolicies []RetentionPolicy `json:"execution_task_policies"`
+	BatchSize             int               `json:"batch_size"`
+
+	ArchiveDir    string `json:"archive_dir"`    // archive the pruned rows to gzipped jsonl files in the dir, empty to disable
+	ArchiveBucket string `json:"archive_bucket"` // upload the archive files to the greenfield bucket, empty to disable
+}
+
+// RetentionPolicy prunes the rows in the status not updated for longer than the retention
+type RetentionPolicy struct {
+	Status    int   `json:"status"`
+	Retention int64 `json:"retention"` // in seconds
+}
+
+func (cfg *PruneConfig) Validate() {
+	for _, policy := range append(cfg.EventLogPolicies, cfg.ExecutionTaskPolicies...) {
+		if policy.Retention <= 0 {
+			panic("retention should be larger than 0")
+		}
+	}
+	if cfg.BatchSize < 0 {
+		panic("batch_size should not be negative")
+	}
+	if cfg.ArchiveBucket != "" && cfg.ArchiveDir == "" {
+		panic("archive_dir should not be empty if archive to bucket")
+	}
+}
+
+// GetBatchSize returns the number of rows pruned in a batch
+func (cfg *PruneConfig) GetBatchSize() int {
+	if cfg.BatchSize == 0 {
+		return common.DefaultPruneBatchSize
+	}
+	return cfg.BatchSize
 }
 
 type AlertConfig struct {
@@ -47,6 +338,7 @@ type AlertConfig struct {
 	SlackApp string `json:"slack_app"`
 
 	BlockUpdateTimeout int64 `json:"block_update_timeout"`
+	ReorgAlertDepth    int64 `json:"reorg_alert_depth"` // alert if the reorg is deeper than this, 0 to disable
 }
 
 func (cfg *AlertConfig) Validate() {
@@ -55,14 +347,25 @@ func (cfg *AlertConfig) Validate() {
 	}
 }
 
+// HTTPConfig is the http server of the component serving the status api, the server is disabled if it is not set
+type HTTPConfig struct {
+	ListenAddr string `json:"listen_addr"` // e.g. ":8080"
+}
+
+func (cfg *HTTPConfig) Validate() {
+	if cfg.ListenAddr == "" {
+		panic("listen_addr should not be empty")
+	}
+}
+
 type DBConfig struct {
 	Dialect string `json:"dialect"`
 	DBPath  string `json:"db_path"`
 }
 
 func (cfg *DBConfig) Validate() {
-	if cfg.Dialect != common.DBDialectMysql && cfg.Dialect != common.DBDialectSqlite3 {
-		panic(fmt.Sprintf("only %s and %s supported", common.DBDialectMysql, common.DBDialectSqlite3))
+	if cfg.Dialect != common.DBDialectMysql && cfg.Dialect != common.DBDialectSqlite3 && cfg.Dialect != common.DBDialectPostgres {
+		panic(fmt.Sprintf("only %s, %s and %s supported", common.DBDialectMysql, common.DBDialectSqlite3, common.DBDialectPostgres))
 	}
 	if cfg.DBPath == "" {
 		panic("db path should not be empty")
@@ -95,13 +398,34 @@ func (cfg *LogConfig) Validate() {
 }
 
 type GreenfieldConfig struct {
-	RPCAddr       string `json:"rpc_addr"`
-	PrivateKey    string `json:"private_key"`
-	ChainId       uint64 `json:"chain_id"`
-	StartHeight   int64  `json:"start_height"`
-	GasLimit      uint64 `json:"gas_limit"`
-	FeeAmount     uint64 `json:"fee_amount"`
-	ChainIdString string `json:"chain_id_string"`
+	RPCAddr       string   `json:"rpc_addr"`
+	RPCAddrs      []string `json:"rpc_addrs"` // overrides rpc_addr, the healthiest endpoint is used
+	PrivateKey    string   `json:"private_key"`
+	ChainId       uint64   `json:"chain_id"`
+	StartHeight   int64    `json:"start_height"`
+	GasLimit      uint64   `json:"gas_limit"`
+	FeeAmount     uint64   `json:"fee_amount"`
+	ChainIdString string   `json:"chain_id_string"`
+
+	// CrossCheckBlockHash compares the fetched block hashes between endpoints to detect a lying endpoint
+	CrossCheckBlockHash bool `json:"cross_check_block_hash"`
+}
+
+func (cfg *GreenfieldConfig) Validate() {
+	if len(cfg.GetRPCAddrs()) == 0 {
+		panic("rpc_addr or rpc_addrs should not be empty")
+	}
+}
+
+// GetRPCAddrs returns the addresses of rpc endpoints
+func (cfg *GreenfieldConfig) GetRPCAddrs() []string {
+	if len(cfg.RPCAddrs) != 0 {
+		return cfg.RPCAddrs
+	}
+	if cfg.RPCAddr != "" {
+		return []string{cfg.RPCAddr}
+	}
+	return nil
 }
 
 // ParseObserverConfigFromFile returns the config from json file
@@ -145,3 +469,22 @@ func ParseSenderConfigFromFile(filePath string) *SenderConfig {
 	}
 	return &config
 }
+
+// ParseDBConfigFromFile returns the db config from the json config file of any component
+func ParseDBConfigFromFile(filePath string) *DBConfig {
+	bz, err := os.ReadFile(filePath)
+	if err != nil {
+		panic(err)
+	}
+
+	var config struct {
+		DBConfig *DBConfig `json:"db_config"`
+	}
+	if err := json.Unmarshal(bz, &config); err != nil {
+		panic(err)
+	}
+	if config.DBConfig == nil {
+		panic("db_config should not be empty")
+	}
+	return config.DBConfig
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck returns nil if the thing checked is healthy, or the reason why it is not
+type HealthCheck func() error
+
+// Heartbeat is beaten by a routine on each round, the routine is considered wedged if it is not beaten in time
+type Heartbeat struct {
+	timeout time.Duration
+	last    atomic.Int64 // unix time in milliseconds
+}
+
+// NewHeartbeat returns a heartbeat beaten now, so the routine has the timeout to start
+func NewHeartbeat(timeout time.Duration) *Heartbeat {
+	h := &Heartbeat{timeout: timeout}
+	h.Beat()
+	return h
+}
+
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixMilli())
+}
+
+// Check returns an error if the heartbeat is not beaten within the timeout
+func (h *Heartbeat) Check() error {
+	elapsed := time.Since(time.UnixMilli(h.last.Load()))
+	if elapsed > h.timeout {
+		return fmt.Errorf("no heartbeat for %s, timeout=%s", elapsed.Truncate(time.Second), h.timeout)
+	}
+	return nil
+}
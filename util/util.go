@@ -132,3 +132,13 @@ func QuotedStrToIntWithBitSize(str string, bitSize int) (int64, error) {
 	}
 	return num, nil
 }
+
+// Contains returns whether the string slice contains the value
+func Contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}